@@ -28,7 +28,14 @@ func setupCleanSlateTest(t *testing.T) (*MindloopHandler, *gorm.DB) {
 		&models.JournalEntry{},
 		&models.Habit{},
 		&models.HabitLog{},
+		&models.HabitPause{},
+		&models.HabitEvent{},
+		&models.HabitRevision{},
+		&models.Routine{},
+		&models.RoutineStep{},
+		&models.RoutineRun{},
 		&models.FocusSession{},
+		&models.FocusSegment{},
 		&models.Intent{},
 	)
 	if err != nil {
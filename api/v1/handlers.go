@@ -78,12 +78,12 @@ func (mlh *MindloopHandler) renderTemplate(w http.ResponseWriter, tmpl string, d
 func (mlh *MindloopHandler) HandleHome(w http.ResponseWriter, r *http.Request) {
 	// Gather Dashboard Stats
 	// 1. Active Habits
-	habits, _ := mlh.habit.ListHabits("")
+	habits, _ := mlh.habit.ListHabits(habit.ListOptions{})
 	activeHabits := len(habits)
 
 	// 2. Focus Time Today
 	now := time.Now()
-	todayStart := now.Truncate(24 * time.Hour)
+	todayStart := mlh.habit.Calendar.StartOfDay(now)
 	focusStats, _ := mlh.summary.GetFocusStats(todayStart, now)
 
 	// 3. Last Journal Mood
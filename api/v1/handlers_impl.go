@@ -3,29 +3,36 @@ package v1
 import (
 	"errors"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/rs/zerolog/log"
+	"github.com/snehmatic/mindloop/internal/core/focus"
+	"github.com/snehmatic/mindloop/internal/core/habit"
 	"github.com/snehmatic/mindloop/models"
 )
 
 // --- Habit Handlers ---
 
 func (mlh *MindloopHandler) HandleHabitList(w http.ResponseWriter, r *http.Request) {
-	interval := r.URL.Query().Get("interval")
-	if interval == "" {
-		interval = string(models.Daily)
+	// empty shows every interval and tag
+	opts := habit.ListOptions{
+		Interval: models.IntervalType(r.URL.Query().Get("interval")),
+		Tag:      r.URL.Query().Get("tag"),
 	}
 
-	habits, err := mlh.habit.ListHabits(models.IntervalType(interval))
+	habits, err := mlh.habit.ListHabits(habit.ListOptions{Interval: opts.Interval, Tag: opts.Tag, IncludeArchived: true})
 	if err != nil {
 		log.Error().Err(err).Msg("Error listing habits")
 		http.Error(w, "Error fetching habits", http.StatusInternalServerError)
 		return
 	}
 
-	habitLogs, err := mlh.habit.ListHabitLogs(models.IntervalType(interval))
+	habitLogs, err := mlh.habit.ListHabitLogs(opts)
 	if err != nil {
 		log.Error().Err(err).Msg("Error listing habit logs")
 	}
@@ -33,42 +40,35 @@ func (mlh *MindloopHandler) HandleHabitList(w http.ResponseWriter, r *http.Reque
 	// Calculate completion for UI
 	type HabitView struct {
 		models.Habit
-		ActualCount int
-		ProgressPct int
+		ActualCount    int
+		ProgressPct    int
+		ScheduledToday bool
+		Pause          *models.HabitPause // pause covering today, if any
+		Skipped        *models.HabitLog   // log of the current period if it is skipped
+		Streak         models.HabitStreak
 	}
 
+	cal := mlh.habit.Calendar
+	now := time.Now()
 	var habitViews []HabitView
+	var archived []models.Habit
 	for _, h := range habits {
-		actual := 0
-		for _, log := range habitLogs {
-			// Basic match for today/current interval - simplified logic for UI
-			if log.HabitID == h.ID {
-				// Check if the log is "current" (today for daily)
-				// Simplify: just taking the log count if it matches.
-				// In a real app, `ListHabitLogs` should filter by date range or we filter here.
-				// For now, let's assume `ListHabitLogs` returns all, but we only really care about "current" status
-				// This part ideally needs the service to return "HabitWithStatus".
-				// Re-using service logic:
-				// We'll iterate and find if there's a log for *today* (created_at)
-				isToday := false
-				if h.Interval == models.Daily {
-					if log.CreatedAt.Truncate(24 * time.Hour).Equal(time.Now().Truncate(24 * time.Hour)) {
-						isToday = true
-					}
-				} else {
-					// Weekly check simplified
-					// For weekly, we check if created_at is within this week.
-					year, week := time.Now().ISOWeek()
-					logYear, logWeek := log.CreatedAt.ISOWeek()
-					if year == logYear && week == logWeek {
-						isToday = true
-					}
-				}
+		if h.IsArchived() {
+			archived = append(archived, h)
+			continue
+		}
 
-				if isToday {
-					actual = log.ActualCount
-					break
+		// Only the log of the current interval counts towards progress
+		current := habit.PeriodFor(cal, &h, now)
+		actual := 0
+		var skipped *models.HabitLog
+		for i, log := range habitLogs {
+			if log.HabitID == h.ID && log.EndedAt.Equal(current.End) {
+				actual = log.ActualCount
+				if log.Skipped {
+					skipped = &habitLogs[i]
 				}
+				break
 			}
 		}
 		pct := 0
@@ -78,16 +78,74 @@ func (mlh *MindloopHandler) HandleHabitList(w http.ResponseWriter, r *http.Reque
 		if pct > 100 {
 			pct = 100
 		}
+		streak, err := mlh.habit.GetStreak(&h)
+		if err != nil {
+			log.Error().Err(err).Uint("habit_id", h.ID).Msg("Error calculating habit streak")
+		}
 		habitViews = append(habitViews, HabitView{
-			Habit:       h,
-			ActualCount: actual,
-			ProgressPct: pct,
+			Habit:          h,
+			ActualCount:    actual,
+			ProgressPct:    pct,
+			ScheduledToday: habit.IsScheduled(cal, &h, now),
+			Pause:          h.PauseCovering(cal, cal.Day(now)),
+			Skipped:        skipped,
+			Streak:         streak,
 		})
 	}
 
+	// Habits are grouped by tag, untagged ones last. Habits with several
+	// tags show up in each group, unless filtering by one of them.
+	type HabitGroup struct {
+		Tag    string // "" for untagged habits
+		Habits []HabitView
+	}
+	var groups []HabitGroup
+	groupIndex := map[string]int{}
+	addToGroup := func(tag string, hv HabitView) {
+		i, ok := groupIndex[tag]
+		if !ok {
+			i = len(groups)
+			groupIndex[tag] = i
+			groups = append(groups, HabitGroup{Tag: tag})
+		}
+		groups[i].Habits = append(groups[i].Habits, hv)
+	}
+	for _, hv := range habitViews {
+		tags := hv.TagList()
+		if opts.Tag != "" {
+			tags = nil
+		}
+		if len(tags) == 0 {
+			addToGroup("", hv)
+		}
+		for _, tag := range tags {
+			addToGroup(tag, hv)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return models.TagLess(groups[i].Tag, groups[j].Tag) })
+
+	overall, err := mlh.habit.OverallHeatmap(habit.HeatmapWeeks)
+	if err != nil {
+		log.Error().Err(err).Msg("Error building habit heatmap")
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	notes, err := mlh.habit.SearchNotes(query, opts)
+	if err != nil {
+		log.Error().Err(err).Msg("Error searching habit notes")
+	}
+
 	data := map[string]interface{}{
-		"Title":  "Habits",
-		"Habits": habitViews,
+		"Title":    "Habits",
+		"Heatmap":  renderHeatmapSVG(overall),
+		"Legend":   renderHeatmapLegendSVG(),
+		"Habits":   habitViews,
+		"Groups":   groups,
+		"Tag":      models.NormalizeTag(opts.Tag),
+		"Query":    query,
+		"Notes":    models.ToHabitNoteViews(notes),
+		"Archived": archived,
+		"Today":    now.Format("2006-01-02"),
 	}
 
 	// Pass query params as simple alerts
@@ -101,6 +159,31 @@ func (mlh *MindloopHandler) HandleHabitList(w http.ResponseWriter, r *http.Reque
 	mlh.renderTemplate(w, "habits.html", data)
 }
 
+// HandleHabitDetail shows a single habit with its history, /habits/{id}
+// where id is anything habits can be referred to by
+func (mlh *MindloopHandler) HandleHabitDetail(w http.ResponseWriter, r *http.Request) {
+	ref := mux.Vars(r)["id"]
+	analytics, err := mlh.habit.Analytics(ref, 10)
+	if err != nil {
+		log.Error().Err(err).Msg("Error fetching habit")
+		http.Redirect(w, r, "/habits?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+	_, heatmap, err := mlh.habit.Heatmap(ref, habit.HeatmapWeeks)
+	if err != nil {
+		log.Error().Err(err).Msg("Error building habit heatmap")
+	}
+
+	mlh.renderTemplate(w, "habit.html", map[string]interface{}{
+		"Title":     analytics.Habit.Title,
+		"Habit":     analytics.Habit,
+		"Streak":    analytics.Streak,
+		"Analytics": analytics,
+		"Heatmap":   renderHeatmapSVG(heatmap),
+		"Legend":    renderHeatmapLegendSVG(),
+	})
+}
+
 func (mlh *MindloopHandler) HandleHabitCreate(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Redirect(w, r, "/habits", http.StatusSeeOther)
@@ -110,14 +193,51 @@ func (mlh *MindloopHandler) HandleHabitCreate(w http.ResponseWriter, r *http.Req
 	title := r.FormValue("title")
 	targetCount, _ := strconv.Atoi(r.FormValue("target_count"))
 	interval := r.FormValue("interval")
+	everyN, _ := strconv.Atoi(r.FormValue("every_n"))
+	weekdays, _ := models.ParseWeekdays(strings.Join(r.Form["weekdays"], ","))
 
-	habit := &models.Habit{
+	newHabit := &models.Habit{
 		Title:       title,
 		TargetCount: targetCount,
 		Interval:    models.IntervalType(interval),
+		EveryN:      everyN,
+		Weekdays:    models.FormatWeekdays(weekdays),
+		Unit:        strings.TrimSpace(r.FormValue("unit")),
+		Tags:        models.FormatTags(models.ParseTags(r.FormValue("tags"))),
+	}
+	if at := strings.TrimSpace(r.FormValue("remind_at")); at != "" {
+		remindAt, err := models.ParseTimeOfDay(at)
+		if err != nil {
+			http.Redirect(w, r, "/habits?error=Invalid reminder time", http.StatusSeeOther)
+			return
+		}
+		newHabit.RemindAt = remindAt
+	}
+	// Either end of the time window may be left open
+	if at := strings.TrimSpace(r.FormValue("window_start")); at != "" {
+		start, err := models.ParseTimeOfDay(at)
+		if err != nil {
+			http.Redirect(w, r, "/habits?error=Invalid time window", http.StatusSeeOther)
+			return
+		}
+		newHabit.WindowStart = start
+	}
+	if at := strings.TrimSpace(r.FormValue("window_end")); at != "" {
+		end, err := models.ParseTimeOfDay(at)
+		if err != nil {
+			http.Redirect(w, r, "/habits?error=Invalid time window", http.StatusSeeOther)
+			return
+		}
+		newHabit.WindowEnd = end
+	}
+	if r.FormValue("kind") == string(models.KindAvoid) {
+		newHabit.Kind = models.KindAvoid
+		newHabit.TargetCount = 0
+		newHabit.Unit = ""
+		newHabit.RemindAt = ""
 	}
 
-	if err := mlh.habit.CreateHabit(habit); err != nil {
+	if err := mlh.habit.CreateHabit(newHabit); err != nil {
 		log.Error().Err(err).Msg("Error creating habit")
 		http.Redirect(w, r, "/habits?error=Failed to create habit", http.StatusSeeOther)
 		return
@@ -133,7 +253,14 @@ func (mlh *MindloopHandler) HandleHabitLog(w http.ResponseWriter, r *http.Reques
 	}
 
 	habitID := r.FormValue("habit_id")
-	_, _, err := mlh.habit.LogHabit(habitID)
+	date, err := parseFormDate(r.FormValue("date"), mlh.habit.Calendar.Location)
+	if err != nil {
+		http.Redirect(w, r, "/habits?error=Invalid date", http.StatusSeeOther)
+		return
+	}
+	amount, _ := strconv.Atoi(r.FormValue("amount")) // empty means a single check-in
+	note := strings.TrimSpace(r.FormValue("note"))
+	_, _, err = mlh.habit.LogHabit(habitID, habit.LogOptions{Date: date, Amount: amount, Note: note})
 	if err != nil {
 		log.Error().Err(err).Msg("Error logging habit")
 		http.Redirect(w, r, "/habits?error="+err.Error(), http.StatusSeeOther)
@@ -150,7 +277,13 @@ func (mlh *MindloopHandler) HandleHabitUnlog(w http.ResponseWriter, r *http.Requ
 	}
 
 	habitID := r.FormValue("habit_id")
-	_, err := mlh.habit.UnlogHabit(habitID)
+	date, err := parseFormDate(r.FormValue("date"), mlh.habit.Calendar.Location)
+	if err != nil {
+		http.Redirect(w, r, "/habits?error=Invalid date", http.StatusSeeOther)
+		return
+	}
+	// Undo only the latest check-in, so a mis-tap doesn't wipe the whole period
+	_, _, err = mlh.habit.UnlogHabit(habitID, habit.LogOptions{Date: date, LastOnly: true})
 	if err != nil {
 		log.Error().Err(err).Msg("Error Unlogging habit")
 		http.Redirect(w, r, "/habits?error="+err.Error(), http.StatusSeeOther)
@@ -159,6 +292,14 @@ func (mlh *MindloopHandler) HandleHabitUnlog(w http.ResponseWriter, r *http.Requ
 	http.Redirect(w, r, "/habits?success=true", http.StatusSeeOther)
 }
 
+// parseFormDate parses an optional date picker value, empty means today
+func parseFormDate(value string, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, loc)
+}
+
 func (mlh *MindloopHandler) HandleHabitDelete(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Redirect(w, r, "/habits", http.StatusSeeOther)
@@ -175,6 +316,139 @@ func (mlh *MindloopHandler) HandleHabitDelete(w http.ResponseWriter, r *http.Req
 	http.Redirect(w, r, "/habits?success=true", http.StatusSeeOther)
 }
 
+func (mlh *MindloopHandler) HandleHabitArchive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/habits", http.StatusSeeOther)
+		return
+	}
+
+	habitID := r.FormValue("habit_id")
+	var err error
+	if r.FormValue("undo") == "true" {
+		_, err = mlh.habit.UnarchiveHabit(habitID)
+	} else {
+		_, err = mlh.habit.ArchiveHabit(habitID)
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Error archiving habit")
+		http.Redirect(w, r, "/habits?error="+err.Error(), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/habits?success=true", http.StatusSeeOther)
+}
+
+// HandleHabitMove moves a habit one place up or down the list
+func (mlh *MindloopHandler) HandleHabitMove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/habits", http.StatusSeeOther)
+		return
+	}
+
+	habitID := r.FormValue("habit_id")
+	var offset int
+	switch r.FormValue("direction") {
+	case "up":
+		offset = -1
+	case "down":
+		offset = 1
+	default:
+		http.Redirect(w, r, "/habits?error=Invalid direction", http.StatusSeeOther)
+		return
+	}
+	if _, _, err := mlh.habit.MoveHabitBy(habitID, offset); err != nil {
+		log.Error().Err(err).Msg("Error moving habit")
+		http.Redirect(w, r, "/habits?error="+err.Error(), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/habits?success=true", http.StatusSeeOther)
+}
+
+// HandleHabitPin pins a habit to the top of the list, or unpins it with undo=true
+func (mlh *MindloopHandler) HandleHabitPin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/habits", http.StatusSeeOther)
+		return
+	}
+
+	habitID := r.FormValue("habit_id")
+	if _, err := mlh.habit.PinHabit(habitID, r.FormValue("undo") != "true"); err != nil {
+		log.Error().Err(err).Msg("Error pinning habit")
+		http.Redirect(w, r, "/habits?error="+err.Error(), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/habits?success=true", http.StatusSeeOther)
+}
+
+func (mlh *MindloopHandler) HandleHabitPause(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/habits", http.StatusSeeOther)
+		return
+	}
+
+	habitID := r.FormValue("habit_id")
+	loc := mlh.habit.Calendar.Location
+	from, err := parseFormDate(r.FormValue("from"), loc)
+	if err != nil {
+		http.Redirect(w, r, "/habits?error=Invalid date", http.StatusSeeOther)
+		return
+	}
+	until, err := parseFormDate(r.FormValue("until"), loc) // empty pauses until resumed
+	if err != nil {
+		http.Redirect(w, r, "/habits?error=Invalid date", http.StatusSeeOther)
+		return
+	}
+	_, _, err = mlh.habit.PauseHabit(habitID, from, until, strings.TrimSpace(r.FormValue("reason")))
+	if err != nil {
+		log.Error().Err(err).Msg("Error pausing habit")
+		http.Redirect(w, r, "/habits?error="+err.Error(), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/habits?success=true", http.StatusSeeOther)
+}
+
+// HandleHabitSkip skips the period of the given date, or takes the skip
+// back with undo=true
+func (mlh *MindloopHandler) HandleHabitSkip(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/habits", http.StatusSeeOther)
+		return
+	}
+
+	habitID := r.FormValue("habit_id")
+	date, err := parseFormDate(r.FormValue("date"), mlh.habit.Calendar.Location)
+	if err != nil {
+		http.Redirect(w, r, "/habits?error=Invalid date", http.StatusSeeOther)
+		return
+	}
+	if r.FormValue("undo") == "true" {
+		_, _, err = mlh.habit.UnskipHabit(habitID, date)
+	} else {
+		_, _, err = mlh.habit.SkipHabit(habitID, date, strings.TrimSpace(r.FormValue("reason")))
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Error skipping habit")
+		http.Redirect(w, r, "/habits?error="+err.Error(), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/habits?success=true", http.StatusSeeOther)
+}
+
+func (mlh *MindloopHandler) HandleHabitResume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/habits", http.StatusSeeOther)
+		return
+	}
+
+	habitID := r.FormValue("habit_id")
+	_, err := mlh.habit.ResumeHabit(habitID)
+	if err != nil {
+		log.Error().Err(err).Msg("Error resuming habit")
+		http.Redirect(w, r, "/habits?error="+err.Error(), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/habits?success=true", http.StatusSeeOther)
+}
+
 // --- Intent Handlers ---
 
 func (mlh *MindloopHandler) HandleIntent(w http.ResponseWriter, r *http.Request) {
@@ -190,6 +464,8 @@ func (mlh *MindloopHandler) HandleIntent(w http.ResponseWriter, r *http.Request)
 		"Title":         "Intent",
 		"CurrentIntent": currentIntent,
 		"History":       allIntents,
+		"Now":           time.Now(),
+		"IdleCutoff":    mlh.focus.IdleCutoff,
 	})
 }
 
@@ -231,8 +507,10 @@ func (mlh *MindloopHandler) HandleFocus(w http.ResponseWriter, r *http.Request)
 	}
 
 	mlh.renderTemplate(w, "focus.html", map[string]interface{}{
-		"Title":    "Focus",
-		"Sessions": sessions,
+		"Title":      "Focus",
+		"Sessions":   sessions,
+		"Now":        time.Now(),
+		"IdleCutoff": mlh.focus.IdleCutoff,
 	})
 }
 
@@ -242,7 +520,11 @@ func (mlh *MindloopHandler) HandleFocusStart(w http.ResponseWriter, r *http.Requ
 		return
 	}
 	title := r.FormValue("title")
-	_, err := mlh.focus.StartSession(title)
+	var opts focus.StartOptions
+	if minutes, err := strconv.Atoi(r.FormValue("minutes")); err == nil {
+		opts.Planned = time.Duration(minutes) * time.Minute
+	}
+	_, err := mlh.focus.StartSession(title, opts)
 	if err != nil {
 		log.Error().Err(err).Msg("Error starting focus session")
 	}
@@ -263,25 +545,68 @@ func (mlh *MindloopHandler) HandleFocusStop(w http.ResponseWriter, r *http.Reque
 	http.Redirect(w, r, "/focus", http.StatusSeeOther)
 }
 
+// HandleFocusPause pauses an active session, or resumes a paused one with undo=true
+func (mlh *MindloopHandler) HandleFocusPause(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/focus", http.StatusSeeOther)
+		return
+	}
+	id, _ := strconv.Atoi(r.FormValue("id"))
+	var err error
+	if r.FormValue("undo") == "true" {
+		_, err = mlh.focus.ResumeSession(id)
+	} else {
+		_, err = mlh.focus.PauseSession(id)
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Error pausing focus session")
+	}
+	http.Redirect(w, r, "/focus", http.StatusSeeOther)
+}
+
+// HandleFocusFix sets when a stale session really ended, end being a
+// datetime-local value
+func (mlh *MindloopHandler) HandleFocusFix(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/focus", http.StatusSeeOther)
+		return
+	}
+	id, _ := strconv.Atoi(r.FormValue("id"))
+	end, err := time.ParseInLocation("2006-01-02T15:04", r.FormValue("end"), mlh.habit.Calendar.Location)
+	if err != nil {
+		log.Error().Err(err).Msg("Invalid focus session end")
+		http.Redirect(w, r, "/focus", http.StatusSeeOther)
+		return
+	}
+	if _, err := mlh.focus.FixSession(id, end); err != nil {
+		log.Error().Err(err).Msg("Error fixing focus session")
+	}
+	http.Redirect(w, r, "/focus", http.StatusSeeOther)
+}
+
 // --- Summary Handler ---
 
 func (mlh *MindloopHandler) HandleSummary(w http.ResponseWriter, r *http.Request) {
+	cal := mlh.habit.Calendar
 	now := time.Now()
-	start := now.AddDate(0, 0, -7) // Default to Last 7 days
+	start := cal.Week(now).Start // Default to this week
+	heading := "Weekly Summary"
 
 	// Parse Custom Range
 	startParam := r.URL.Query().Get("start")
 	endParam := r.URL.Query().Get("end")
 
 	if startParam != "" {
-		if parsedStart, err := time.Parse("2006-01-02", startParam); err == nil {
+		if parsedStart, err := time.ParseInLocation("2006-01-02", startParam, cal.Location); err == nil {
 			start = parsedStart
+			heading = "Summary Report"
 		}
 	}
 	if endParam != "" {
-		if parsedEnd, err := time.Parse("2006-01-02", endParam); err == nil {
+		if parsedEnd, err := time.ParseInLocation("2006-01-02", endParam, cal.Location); err == nil {
 			// Set end to end of that day
-			now = parsedEnd.Add(24*time.Hour - 1*time.Second)
+			now = cal.Day(parsedEnd).Until().Add(-1 * time.Second)
+			heading = "Summary Report"
 		}
 	}
 
@@ -291,6 +616,7 @@ func (mlh *MindloopHandler) HandleSummary(w http.ResponseWriter, r *http.Request
 		// Render with error message
 		mlh.renderTemplate(w, "summary.html", map[string]interface{}{
 			"Title":        "Summary",
+			"Heading":      heading,
 			"ErrorMessage": "Failed to generate summary: " + err.Error(),
 			"Report":       models.SummaryReport{DateRange: "Unavailable"},
 		})
@@ -298,8 +624,9 @@ func (mlh *MindloopHandler) HandleSummary(w http.ResponseWriter, r *http.Request
 	}
 
 	mlh.renderTemplate(w, "summary.html", map[string]interface{}{
-		"Title":  "Summary",
-		"Report": report,
+		"Title":   "Summary",
+		"Heading": heading,
+		"Report":  report,
 	})
 }
 
@@ -4,9 +4,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/gorilla/mux"
 	v1 "github.com/snehmatic/mindloop/api/v1"
 	"github.com/snehmatic/mindloop/internal/core/focus"
 	"github.com/snehmatic/mindloop/internal/core/habit"
@@ -36,7 +39,14 @@ func setupTestServer(t *testing.T) *v1.MindloopHandler {
 		&models.JournalEntry{},
 		&models.Habit{},
 		&models.HabitLog{},
+		&models.HabitPause{},
+		&models.HabitEvent{},
+		&models.HabitRevision{},
+		&models.Routine{},
+		&models.RoutineStep{},
+		&models.RoutineRun{},
 		&models.FocusSession{},
+		&models.FocusSegment{},
 		&models.Intent{},
 	)
 	if err != nil {
@@ -58,6 +68,18 @@ func setupTestServer(t *testing.T) *v1.MindloopHandler {
 	)
 }
 
+// postForm posts the form values to the handler and returns where it
+// redirects to, nil if it doesn't
+func postForm(t *testing.T, handler http.HandlerFunc, path string, val url.Values) *url.URL {
+	t.Helper()
+	req := httptest.NewRequest("POST", path, strings.NewReader(val.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handler(w, req)
+	loc, _ := w.Result().Location()
+	return loc
+}
+
 func TestHabitFlow(t *testing.T) {
 	mlh := setupTestServer(t)
 
@@ -155,3 +177,364 @@ func TestSummaryGeneration(t *testing.T) {
 		t.Errorf("Summary page content missing expected title")
 	}
 }
+
+func TestHabitCreateSchedules(t *testing.T) {
+	mlh := setupTestServer(t)
+
+	cases := []url.Values{
+		{"title": {"Budget"}, "target_count": {"1"}, "interval": {"monthly"}},
+		{"title": {"Plants"}, "target_count": {"1"}, "interval": {"every_n_days"}, "every_n": {"3"}},
+		{"title": {"Gym"}, "target_count": {"1"}, "interval": {"weekdays"}, "weekdays": {"mon", "wed", "fri"}},
+	}
+	for _, val := range cases {
+		if loc := postForm(t, mlh.HandleHabitCreate, "/habits/new", val); loc == nil || !strings.Contains(loc.String(), "success=true") {
+			t.Errorf("Create %s habit failed, redirected to %v", val.Get("interval"), loc)
+		}
+	}
+
+	// Weekdays habits need at least one day
+	val := url.Values{"title": {"Nope"}, "target_count": {"1"}, "interval": {"weekdays"}}
+	if loc := postForm(t, mlh.HandleHabitCreate, "/habits/new", val); loc == nil || !strings.Contains(loc.String(), "error=") {
+		t.Errorf("Expected weekdays habit without days to fail, got %v", loc)
+	}
+
+	w := httptest.NewRecorder()
+	mlh.HandleHabitList(w, httptest.NewRequest("GET", "/habits", nil))
+	body := w.Body.String()
+	for _, want := range []string{"Budget", "every 3 days", "mon, wed, fri"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Habit list missing %q", want)
+		}
+	}
+}
+
+func TestHabitBackfill(t *testing.T) {
+	mlh := setupTestServer(t)
+
+	postForm(t, mlh.HandleHabitCreate, "/habits/new", url.Values{"title": {"Workout"}, "target_count": {"1"}, "interval": {"daily"}})
+
+	logFor := func(date string) *url.URL {
+		return postForm(t, mlh.HandleHabitLog, "/habits/log", url.Values{"habit_id": {"1"}, "date": {date}})
+	}
+
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	if loc := logFor(yesterday); loc == nil || !strings.Contains(loc.String(), "success=true") {
+		t.Errorf("Backfilling yesterday failed: %v", loc)
+	}
+	// Yesterday is complete now, logging it again must not touch today
+	if loc := logFor(yesterday); loc == nil || !strings.Contains(loc.String(), "error=") {
+		t.Errorf("Expected second backfill of a completed day to fail, got %v", loc)
+	}
+	if loc := logFor(""); loc == nil || !strings.Contains(loc.String(), "success=true") {
+		t.Errorf("Logging today failed: %v", loc)
+	}
+
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	if loc := logFor(tomorrow); loc == nil || !strings.Contains(loc.String(), "error=") {
+		t.Errorf("Expected future date to be rejected, got %v", loc)
+	}
+}
+
+func TestHabitMeasurable(t *testing.T) {
+	mlh := setupTestServer(t)
+
+	postForm(t, mlh.HandleHabitCreate, "/habits/new", url.Values{"title": {"Water"}, "target_count": {"2000"}, "interval": {"daily"}, "unit": {"ml"}})
+
+	for _, amount := range []string{"500", "1750"} {
+		if loc := postForm(t, mlh.HandleHabitLog, "/habits/log", url.Values{"habit_id": {"1"}, "amount": {amount}}); loc == nil || !strings.Contains(loc.String(), "success=true") {
+			t.Errorf("Logging %s ml failed: %v", amount, loc)
+		}
+	}
+
+	// Going past the target is fine for measurable habits
+	w := httptest.NewRecorder()
+	mlh.HandleHabitList(w, httptest.NewRequest("GET", "/habits", nil))
+	if body := w.Body.String(); !strings.Contains(body, "2250 / 2000 ml") {
+		t.Errorf("Expected progress in ml to be rendered")
+	}
+}
+
+func TestHabitArchiveAndPause(t *testing.T) {
+	mlh := setupTestServer(t)
+
+	postForm(t, mlh.HandleHabitCreate, "/habits/new", url.Values{"title": {"Gym"}, "target_count": {"1"}, "interval": {"daily"}})
+
+	if loc := postForm(t, mlh.HandleHabitPause, "/habits/pause", url.Values{"habit_id": {"gym"}, "reason": {"vacation"}}); loc == nil || !strings.Contains(loc.String(), "success=true") {
+		t.Fatalf("Pausing habit failed: %v", loc)
+	}
+	w := httptest.NewRecorder()
+	mlh.HandleHabitList(w, httptest.NewRequest("GET", "/habits", nil))
+	if !strings.Contains(w.Body.String(), "paused (vacation)") {
+		t.Errorf("Expected paused habit to be shown as paused")
+	}
+	if loc := postForm(t, mlh.HandleHabitResume, "/habits/resume", url.Values{"habit_id": {"gym"}}); loc == nil || !strings.Contains(loc.String(), "success=true") {
+		t.Errorf("Resuming habit failed: %v", loc)
+	}
+
+	if loc := postForm(t, mlh.HandleHabitArchive, "/habits/archive", url.Values{"habit_id": {"gym"}}); loc == nil || !strings.Contains(loc.String(), "success=true") {
+		t.Fatalf("Archiving habit failed: %v", loc)
+	}
+	if loc := postForm(t, mlh.HandleHabitLog, "/habits/log", url.Values{"habit_id": {"gym"}}); loc == nil || !strings.Contains(loc.String(), "error=") {
+		t.Errorf("Expected logging an archived habit to fail, got %v", loc)
+	}
+	w = httptest.NewRecorder()
+	mlh.HandleHabitList(w, httptest.NewRequest("GET", "/habits", nil))
+	if !strings.Contains(w.Body.String(), "Unarchive") {
+		t.Errorf("Expected archived habit in the archived section")
+	}
+	if loc := postForm(t, mlh.HandleHabitArchive, "/habits/archive", url.Values{"habit_id": {"gym"}, "undo": {"true"}}); loc == nil || !strings.Contains(loc.String(), "success=true") {
+		t.Errorf("Unarchiving habit failed: %v", loc)
+	}
+}
+
+func TestHabitSkip(t *testing.T) {
+	mlh := setupTestServer(t)
+
+	postForm(t, mlh.HandleHabitCreate, "/habits/new", url.Values{"title": {"Gym"}, "target_count": {"1"}, "interval": {"daily"}})
+
+	if loc := postForm(t, mlh.HandleHabitSkip, "/habits/skip", url.Values{"habit_id": {"gym"}, "reason": {"sick"}}); loc == nil || !strings.Contains(loc.String(), "success=true") {
+		t.Fatalf("Skipping habit failed: %v", loc)
+	}
+	w := httptest.NewRecorder()
+	mlh.HandleHabitList(w, httptest.NewRequest("GET", "/habits", nil))
+	if body := w.Body.String(); !strings.Contains(body, "card-skipped") || !strings.Contains(body, "skipped (sick)") {
+		t.Errorf("Expected skipped habit to be shown as skipped")
+	}
+
+	// The skipped day is not due, so it doesn't drag the completion rate down
+	w = httptest.NewRecorder()
+	mlh.HandleSummary(w, httptest.NewRequest("GET", "/summary", nil))
+	if !strings.Contains(w.Body.String(), "0 due • 1 skipped") {
+		t.Errorf("Expected the skipped day to leave the summary denominator")
+	}
+
+	if loc := postForm(t, mlh.HandleHabitSkip, "/habits/skip", url.Values{"habit_id": {"gym"}, "undo": {"true"}}); loc == nil || !strings.Contains(loc.String(), "success=true") {
+		t.Errorf("Unskipping habit failed: %v", loc)
+	}
+	if loc := postForm(t, mlh.HandleHabitSkip, "/habits/skip", url.Values{"habit_id": {"gym"}, "undo": {"true"}}); loc == nil || !strings.Contains(loc.String(), "error=") {
+		t.Errorf("Expected unskipping a period that is not skipped to fail, got %v", loc)
+	}
+
+	// Done periods can't be skipped
+	postForm(t, mlh.HandleHabitLog, "/habits/log", url.Values{"habit_id": {"gym"}})
+	if loc := postForm(t, mlh.HandleHabitSkip, "/habits/skip", url.Values{"habit_id": {"gym"}}); loc == nil || !strings.Contains(loc.String(), "error=") {
+		t.Errorf("Expected skipping a completed period to fail, got %v", loc)
+	}
+}
+
+func TestHabitOrder(t *testing.T) {
+	mlh := setupTestServer(t)
+
+	for _, title := range []string{"Gym", "Read", "Walk"} {
+		postForm(t, mlh.HandleHabitCreate, "/habits/new", url.Values{"title": {title}, "target_count": {"1"}, "interval": {"daily"}})
+	}
+	order := func() string {
+		w := httptest.NewRecorder()
+		mlh.HandleHabitList(w, httptest.NewRequest("GET", "/habits", nil))
+		body := w.Body.String()
+		titles := []string{"Gym", "Read", "Walk"}
+		slices.SortFunc(titles, func(a, b string) int {
+			return strings.Index(body, ">"+a+"</a>") - strings.Index(body, ">"+b+"</a>")
+		})
+		return strings.Join(titles, ",")
+	}
+
+	if loc := postForm(t, mlh.HandleHabitMove, "/habits/move", url.Values{"habit_id": {"walk"}, "direction": {"up"}}); loc == nil || !strings.Contains(loc.String(), "success=true") {
+		t.Fatalf("Moving habit failed: %v", loc)
+	}
+	if got := order(); got != "Gym,Walk,Read" {
+		t.Errorf("Expected walk to move up, got %s", got)
+	}
+
+	if loc := postForm(t, mlh.HandleHabitPin, "/habits/pin", url.Values{"habit_id": {"read"}}); loc == nil || !strings.Contains(loc.String(), "success=true") {
+		t.Fatalf("Pinning habit failed: %v", loc)
+	}
+	if got := order(); got != "Read,Gym,Walk" {
+		t.Errorf("Expected the pinned habit first, got %s", got)
+	}
+	postForm(t, mlh.HandleHabitMove, "/habits/move", url.Values{"habit_id": {"gym"}, "direction": {"up"}})
+	if got := order(); got != "Read,Gym,Walk" {
+		t.Errorf("Expected habits not to move above pinned ones, got %s", got)
+	}
+
+	postForm(t, mlh.HandleHabitPin, "/habits/pin", url.Values{"habit_id": {"read"}, "undo": {"true"}})
+	if got := order(); got != "Gym,Walk,Read" {
+		t.Errorf("Expected the unpinned habit back at its position, got %s", got)
+	}
+}
+
+func TestFocusPause(t *testing.T) {
+	mlh := setupTestServer(t)
+
+	page := func() string {
+		w := httptest.NewRecorder()
+		mlh.HandleFocus(w, httptest.NewRequest("GET", "/focus", nil))
+		return w.Body.String()
+	}
+	postForm(t, mlh.HandleFocusStart, "/focus/start", url.Values{"title": {"Deep work"}})
+
+	postForm(t, mlh.HandleFocusPause, "/focus/pause", url.Values{"id": {"1"}})
+	if body := page(); !strings.Contains(body, "Paused") || !strings.Contains(body, "Resume") {
+		t.Errorf("Expected the session to show as paused")
+	}
+	postForm(t, mlh.HandleFocusPause, "/focus/pause", url.Values{"id": {"1"}, "undo": {"true"}})
+	if body := page(); !strings.Contains(body, "Active") || !strings.Contains(body, ">Pause<") {
+		t.Errorf("Expected the session to be active again")
+	}
+	postForm(t, mlh.HandleFocusStop, "/focus/stop", url.Values{"id": {"1"}})
+	if body := page(); !strings.Contains(body, "Completed") {
+		t.Errorf("Expected the session to be completed")
+	}
+}
+
+func TestFocusIntent(t *testing.T) {
+	mlh := setupTestServer(t)
+
+	postForm(t, mlh.HandleIntentSet, "/intent/set", url.Values{"name": {"Ship the API"}})
+	postForm(t, mlh.HandleFocusStart, "/focus/start", url.Values{"title": {"Endpoints"}})
+
+	// The session goes to the only active intent
+	w := httptest.NewRecorder()
+	mlh.HandleFocus(w, httptest.NewRequest("GET", "/focus", nil))
+	if !strings.Contains(w.Body.String(), "🎯 Ship the API") {
+		t.Errorf("Expected the session to be linked to the active intent")
+	}
+	w = httptest.NewRecorder()
+	mlh.HandleIntent(w, httptest.NewRequest("GET", "/intent", nil))
+	if !strings.Contains(w.Body.String(), "0min focused so far") {
+		t.Errorf("Expected the intent page to show the time focused on the intent")
+	}
+	w = httptest.NewRecorder()
+	mlh.HandleSummary(w, httptest.NewRequest("GET", "/summary", nil))
+	if !strings.Contains(w.Body.String(), "0min focused") {
+		t.Errorf("Expected the summary to show the time focused per intent")
+	}
+}
+
+func TestHabitNotes(t *testing.T) {
+	mlh := setupTestServer(t)
+
+	postForm(t, mlh.HandleHabitCreate, "/habits/new", url.Values{"title": {"Run"}, "target_count": {"1"}, "interval": {"daily"}})
+	if loc := postForm(t, mlh.HandleHabitLog, "/habits/log", url.Values{"habit_id": {"run"}, "note": {"Personal best, 27:40"}}); loc == nil || !strings.Contains(loc.String(), "success=true") {
+		t.Fatalf("Logging habit with a note failed: %v", loc)
+	}
+
+	// Search ignores case
+	w := httptest.NewRecorder()
+	mlh.HandleHabitList(w, httptest.NewRequest("GET", "/habits?q=BEST", nil))
+	if !strings.Contains(w.Body.String(), "Personal best, 27:40") {
+		t.Errorf("Expected the note to be found")
+	}
+	w = httptest.NewRecorder()
+	mlh.HandleHabitList(w, httptest.NewRequest("GET", "/habits?q=swim", nil))
+	if body := w.Body.String(); strings.Contains(body, "Personal best") || !strings.Contains(body, "No notes found") {
+		t.Errorf("Expected no notes to match")
+	}
+}
+
+func TestHabitUndoLastEvent(t *testing.T) {
+	mlh := setupTestServer(t)
+
+	postForm(t, mlh.HandleHabitCreate, "/habits/new", url.Values{"title": {"Pushups"}, "target_count": {"5"}, "interval": {"daily"}})
+	for range 3 {
+		postForm(t, mlh.HandleHabitLog, "/habits/log", url.Values{"habit_id": {"1"}})
+	}
+	postForm(t, mlh.HandleHabitUnlog, "/habits/unlog", url.Values{"habit_id": {"1"}})
+
+	// A single undo takes back one check-in, not the whole day
+	w := httptest.NewRecorder()
+	mlh.HandleHabitList(w, httptest.NewRequest("GET", "/habits", nil))
+	if !strings.Contains(w.Body.String(), "2 / 5") {
+		t.Errorf("Expected 2 / 5 after undoing one of three check-ins")
+	}
+}
+
+func TestHabitAvoid(t *testing.T) {
+	mlh := setupTestServer(t)
+
+	val := url.Values{"title": {"No sugar"}, "target_count": {"1"}, "interval": {"daily"}, "kind": {"avoid"}}
+	if loc := postForm(t, mlh.HandleHabitCreate, "/habits/new", val); loc == nil || !strings.Contains(loc.String(), "success=true") {
+		t.Fatalf("Creating habit to avoid failed: %v", loc)
+	}
+
+	// Relapses can be logged more than once per period
+	for range 2 {
+		if loc := postForm(t, mlh.HandleHabitLog, "/habits/log", url.Values{"habit_id": {"no-sugar"}}); loc == nil || !strings.Contains(loc.String(), "success=true") {
+			t.Errorf("Logging relapse failed: %v", loc)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	mlh.HandleHabitList(w, httptest.NewRequest("GET", "/habits", nil))
+	body := w.Body.String()
+	if !strings.Contains(body, "Days clean") || !strings.Contains(body, "2 relapse(s) this period") {
+		t.Errorf("Expected relapse stats to be rendered")
+	}
+}
+
+func TestHabitTags(t *testing.T) {
+	mlh := setupTestServer(t)
+
+	for _, val := range []url.Values{
+		{"title": {"Gym"}, "target_count": {"1"}, "interval": {"daily"}, "tags": {"Health, work"}},
+		{"title": {"Meditate"}, "target_count": {"1"}, "interval": {"daily"}, "tags": {"learning"}},
+		{"title": {"Stretch"}, "target_count": {"1"}, "interval": {"daily"}},
+	} {
+		if loc := postForm(t, mlh.HandleHabitCreate, "/habits/new", val); loc == nil || !strings.Contains(loc.String(), "success=true") {
+			t.Fatalf("Creating habit failed: %v", loc)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	mlh.HandleHabitList(w, httptest.NewRequest("GET", "/habits", nil))
+	body := w.Body.String()
+	for _, section := range []string{"#health</h2>", "#learning</h2>", "#work</h2>", "Untagged</h2>"} {
+		if !strings.Contains(body, section) {
+			t.Errorf("Expected a %q section", section)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	mlh.HandleHabitList(w, httptest.NewRequest("GET", "/habits?tag=health", nil))
+	body = w.Body.String()
+	if strings.Count(body, ">Gym<") != 1 || strings.Contains(body, "Meditate") || strings.Contains(body, "Stretch") {
+		t.Errorf("Expected only habits tagged health")
+	}
+
+	// Open periods are not due yet, only the habit done today is summed up
+	if loc := postForm(t, mlh.HandleHabitLog, "/habits/log", url.Values{"habit_id": {"1"}}); loc == nil || !strings.Contains(loc.String(), "success=true") {
+		t.Fatalf("Logging habit failed: %v", loc)
+	}
+	w = httptest.NewRecorder()
+	mlh.HandleSummary(w, httptest.NewRequest("GET", "/summary", nil))
+	if !strings.Contains(w.Body.String(), "By Tag") {
+		t.Errorf("Expected completion per tag in the summary")
+	}
+}
+
+func TestHabitDetail(t *testing.T) {
+	mlh := setupTestServer(t)
+
+	postForm(t, mlh.HandleHabitCreate, "/habits/new", url.Values{"title": {"Gym"}, "target_count": {"1"}, "interval": {"daily"}})
+	postForm(t, mlh.HandleHabitLog, "/habits/log", url.Values{"habit_id": {"gym"}})
+
+	w := httptest.NewRecorder()
+	mlh.HandleHabitDetail(w, mux.SetURLVars(httptest.NewRequest("GET", "/habits/gym", nil), map[string]string{"id": "gym"}))
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, `<svg class="heatmap"`) {
+		t.Fatalf("Expected the habit page with a heatmap, got status %d", w.Code)
+	}
+	if !strings.Contains(body, "100% done") {
+		t.Errorf("Expected today to be shaded as done")
+	}
+	if !strings.Contains(body, "Recent logs") || !strings.Contains(body, "1 of 1 periods") {
+		t.Errorf("Expected the habit analytics to be rendered")
+	}
+
+	// The error is passed on whole, whatever the ref contains
+	w = httptest.NewRecorder()
+	mlh.HandleHabitDetail(w, mux.SetURLVars(httptest.NewRequest("GET", "/habits/nope", nil), map[string]string{"id": "rock & roll"}))
+	if loc, _ := w.Result().Location(); loc == nil || loc.Query().Get("error") != `no matching record found: "rock & roll"` {
+		t.Errorf("Expected an unknown habit to redirect with an error, got %v", loc)
+	}
+}
@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+
+	"github.com/snehmatic/mindloop/models"
+)
+
+const (
+	heatmapCell   = 11 // side of a day's square in px
+	heatmapStep   = 14 // cell plus gap
+	heatmapLeft   = 30 // room for the weekday labels
+	heatmapTop    = 18 // room for the month labels
+	heatmapNotDue = "#f8fafc"
+)
+
+// heatmapColors shade the levels from nothing done to the target reached
+var heatmapColors = [models.HeatmapLevels]string{"#e2e8f0", "#c7d2fe", "#a5b4fc", "#818cf8", "#6366f1"}
+
+// renderHeatmapSVG draws the heatmap as an inline SVG, a column per week
+// and a row per weekday like the CLI version
+func renderHeatmapSVG(hm models.Heatmap) template.HTML {
+	weeks := hm.Weeks()
+	if len(weeks) == 0 {
+		return ""
+	}
+	width := heatmapLeft + len(weeks)*heatmapStep
+	height := heatmapTop + 7*heatmapStep
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg class="heatmap" xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" role="img" aria-label="%s">`,
+		width, height, width, height, html.EscapeString(hm.Title+" heatmap"))
+	b.WriteString(`<g font-size="9" fill="#64748b">`)
+	for _, i := range hm.MonthStarts() {
+		fmt.Fprintf(&b, `<text x="%d" y="10">%s</text>`, heatmapLeft+i*heatmapStep, weeks[i][0].Date.Format("Jan"))
+	}
+	for row := 0; row < min(6, len(weeks[0])); row += 2 {
+		fmt.Fprintf(&b, `<text x="0" y="%d">%s</text>`, heatmapTop+row*heatmapStep+9, weeks[0][row].Date.Format("Mon"))
+	}
+	b.WriteString(`</g>`)
+
+	for i, week := range weeks {
+		for row, d := range week {
+			fill, label := heatmapNotDue, "not due"
+			if d.Skipped {
+				label = "skipped"
+			}
+			if level := d.Level(); level >= 0 {
+				fill = heatmapColors[level]
+				label = fmt.Sprintf("%.0f%% done", d.Ratio*100)
+			}
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" rx="2" fill="%s"><title>%s: %s</title></rect>`,
+				heatmapLeft+i*heatmapStep, heatmapTop+row*heatmapStep, heatmapCell, heatmapCell, fill,
+				d.Date.Format("Mon, Jan 02 2006"), label)
+		}
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// renderHeatmapLegendSVG draws the levels from nothing done to the target reached
+func renderHeatmapLegendSVG() template.HTML {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d">`, len(heatmapColors)*heatmapStep, heatmapCell)
+	for i, fill := range heatmapColors {
+		fmt.Fprintf(&b, `<rect x="%d" width="%d" height="%d" rx="2" fill="%s"/>`, i*heatmapStep, heatmapCell, heatmapCell, fill)
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
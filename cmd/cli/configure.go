@@ -2,8 +2,10 @@ package cli
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/snehmatic/mindloop/internal/config"
+	"github.com/snehmatic/mindloop/internal/period"
 	. "github.com/snehmatic/mindloop/internal/utils"
 	"github.com/snehmatic/mindloop/models"
 	"github.com/spf13/cobra"
@@ -31,6 +33,26 @@ var confCmd = &cobra.Command{
 			PrintWarnln("Invalid mode. Please choose from: local, byodb.")
 		}
 
+		var timezone string
+		for {
+			fmt.Printf("Please enter your timezone, e.g. Europe/Berlin [%s]: ", time.Local.String())
+			fmt.Scanln(&timezone)
+			if _, err := time.LoadLocation(timezone); timezone == "" || err == nil {
+				break
+			}
+			PrintWarnln("Unknown timezone. Please use an IANA name like 'America/New_York'.")
+		}
+
+		var weekStart string
+		for {
+			fmt.Print("Please enter the first day of your week [monday]: ")
+			fmt.Scanln(&weekStart)
+			if _, err := period.ParseWeekday(weekStart); weekStart == "" || err == nil {
+				break
+			}
+			PrintWarnln("Invalid weekday. Please enter e.g. 'monday' or 'sunday'.")
+		}
+
 		dbConfig := &config.DBConfig{}
 		if mode == "byodb" {
 			fmt.Print("Please enter your database host name: ")
@@ -60,7 +82,16 @@ var confCmd = &cobra.Command{
 			}
 		}
 
-		CreateUserConfigYAML(username, mode, dbConfig)
+		var prev config.UserConfig
+		_ = prev.ReadFromYAML() // none on a first run
+		CreateUserConfigYAML(config.UserConfig{
+			Name:      username,
+			Mode:      mode,
+			Timezone:  timezone,
+			WeekStart: weekStart,
+			Reminders: ac.Reminders, // not asked for, keep whatever was set up by hand
+			Focus:     prev.Focus, // same, as written rather than parsed
+		}, dbConfig)
 
 		PrintSuccessf("Configuration complete! Your username is set to: %s, using mode: %s\n", username, mode)
 	},
@@ -70,13 +101,8 @@ func init() {
 	rootCmd.AddCommand(confCmd)
 }
 
-func CreateUserConfigYAML(username, mode string, dbConfig *config.DBConfig) {
-	uc := config.UserConfig{
-		Name: username,
-		Mode: mode,
-	}
-
-	if mode == "byodb" {
+func CreateUserConfigYAML(uc config.UserConfig, dbConfig *config.DBConfig) {
+	if uc.Mode == "byodb" {
 		if dbConfig == nil {
 			PrintWarnln("Database configuration is required for 'byodb' mode. Please try again.")
 			return
@@ -1,9 +1,18 @@
 package cli
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/snehmatic/mindloop/internal/core/focus"
+	"github.com/snehmatic/mindloop/internal/core/intent"
 	. "github.com/snehmatic/mindloop/internal/utils"
 	"github.com/snehmatic/mindloop/models"
 	"github.com/spf13/cobra"
@@ -11,6 +20,10 @@ import (
 
 var (
 	focusService *focus.Service
+	pomodoro     *string
+	focusIntent  *string
+	focusFor     *time.Duration
+	focusFixEnd  *string
 )
 
 var focusCmd = &cobra.Command{
@@ -25,20 +38,57 @@ var focusCmd = &cobra.Command{
 }
 
 var focusStartCmd = &cobra.Command{
-	Use:     "start",
-	Short:   "Start a new focus session",
-	Long:    `Start a new focus session to track your work.`,
-	Example: `mindloop focus start "Work on project"`,
-	Args:    cobra.ExactArgs(1),
+	Use:   "start",
+	Short: "Start a new focus session",
+	Long: `Start a new focus session to track your work.
+With --for the session is planned to last that long and ends by itself once it has, so a
+forgotten session doesn't run on for hours. With --pomodoro the session runs in the foreground
+with a countdown, alternating work and breaks. Ctrl-C ends it early.`,
+	Example: `mindloop focus start "Work on project"
+	mindloop focus start "Write RFC" --for 50m
+	mindloop focus start "Write report" --pomodoro 25/5x4`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		var opts focus.StartOptions
+		if *focusIntent != "" {
+			intentID, err := intent.NewService(gdb).Resolve(*focusIntent)
+			if err != nil {
+				PrintErrorln("Intent not found:", err)
+				return
+			}
+			opts.IntentID = intentID
+		}
+		opts.Planned = *focusFor
+
+		if *pomodoro != "" && opts.Planned != 0 {
+			PrintWarnln("A pomodoro session follows its own plan, please use either --pomodoro or --for.")
+			return
+		}
+
+		if *pomodoro != "" {
+			plan, err := focus.ParsePomodoro(*pomodoro)
+			if err != nil {
+				PrintWarnln(err)
+				return
+			}
+			runPomodoro(cmd.Context(), args[0], plan, opts)
+			return
+		}
+
 		PrintRocketln("That's the spirit! Starting a new focus session...")
-		session, err := focusService.StartSession(args[0])
+		session, err := focusService.StartSession(args[0], opts)
 		if err != nil {
 			PrintErrorln("Error starting focus session:", err)
 			ac.Logger.Error().Msgf("Error starting focus session: %v", err)
 			return
 		}
 		PrintSuccessf("Focus session '%s' started successfully with id %d!\n", session.Title, session.ID)
+		warnStale()
+		if session.IsPlanned() {
+			PrintInfof("Planned for %s, it ends by itself at %s unless you end it first.\n",
+				FormatMinutes(float64(session.PlannedMinutes)), focus.Deadline(*session).Format("15:04"))
+		}
+		printSessionIntent(session)
 		ac.Logger.Info().Msgf("Focus session '%s' started successfully with id %d!", session.Title, session.ID)
 	},
 }
@@ -63,26 +113,27 @@ var focusListCmd = &cobra.Command{
 
 		var views []models.FocusSessionView
 		for _, session := range sessions {
-			views = append(views, models.ToFocusSessionView(session))
+			views = append(views, models.ToFocusSessionView(session, focusService.IdleCutoff))
 		}
 
 		ac.Logger.Info().Msg("Listing all focus sessions.")
 		PrintInfoln("Focus sessions listed below. Note: Duration is in minutes")
 		PrintTable(views)
+		warnStale()
 	},
 }
 
 var focusEndCmd = &cobra.Command{
-	Use:     "end",
-	Short:   "End a focus session",
-	Long:    `End an active focus session to mark it as completed.`,
-	Example: `mindloop focus end <session_id>`,
-	Args:    cobra.ExactArgs(1),
+	Use:   "end",
+	Short: "End a focus session",
+	Long:  `End an active focus session to mark it as completed.`,
+	Example: `mindloop focus end <session_id>
+	mindloop focus end "work on proj"`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		sessionID := args[0]
-		sessionIDInt, err := strconv.Atoi(sessionID)
+		sessionIDInt, err := focusService.ResolveOpen(args[0])
 		if err != nil {
-			PrintErrorln("Error parsing session ID:", err)
+			PrintErrorln("Focus session not found:", err)
 			return
 		}
 
@@ -93,23 +144,165 @@ var focusEndCmd = &cobra.Command{
 			return
 		}
 
-		PrintSuccessf("Focus session '%s' ended successfully!\n", session.Title)
+		PrintSuccessf("Focus session '%s' ended successfully after %s of focus!\n", session.Title, FormatMinutes(session.Duration))
 		PrintRocketln("Great work chief!")
 		ac.Logger.Info().Msgf("Focus session '%s' ended successfully!", session.Title)
 	},
 }
 
+var focusPauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Pause a focus session",
+	Long:  `Pause an active focus session, e.g. for lunch. The time until it is resumed doesn't count as focus.`,
+	Example: `mindloop focus pause <session_id>
+	mindloop focus pause "work on proj"`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sessionIDInt, err := focusService.ResolveOpen(args[0])
+		if err != nil {
+			PrintErrorln("Focus session not found:", err)
+			return
+		}
+
+		session, err := focusService.PauseSession(sessionIDInt)
+		if err != nil {
+			if errors.Is(err, focus.ErrNotActive) {
+				PrintWarnf("Focus session '%s' is %s, only active sessions can be paused.\n", session.Title, session.Status)
+				return
+			}
+			PrintErrorln("Error pausing focus session:", err)
+			ac.Logger.Error().Msgf("Error pausing focus session: %v", err)
+			return
+		}
+
+		PrintSuccessf("Focus session '%s' paused after %s of focus. Resume it with 'mindloop focus resume %d'.\n", session.Title, FormatMinutes(session.Duration), session.ID)
+		ac.Logger.Info().Msgf("Focus session '%s' paused", session.Title)
+	},
+}
+
+var focusResumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume a paused focus session",
+	Example: `mindloop focus resume <session_id>
+	mindloop focus resume "work on proj"`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sessionIDInt, err := focusService.ResolveOpen(args[0])
+		if err != nil {
+			PrintErrorln("Focus session not found:", err)
+			return
+		}
+
+		session, err := focusService.ResumeSession(sessionIDInt)
+		if err != nil {
+			if errors.Is(err, focus.ErrNotPaused) {
+				PrintWarnf("Focus session '%s' is %s, only paused sessions can be resumed.\n", session.Title, session.Status)
+				return
+			}
+			PrintErrorln("Error resuming focus session:", err)
+			ac.Logger.Error().Msgf("Error resuming focus session: %v", err)
+			return
+		}
+
+		PrintRocketf("Back at it! Focus session '%s' resumed.\n", session.Title)
+		ac.Logger.Info().Msgf("Focus session '%s' resumed", session.Title)
+	},
+}
+
+var focusFixCmd = &cobra.Command{
+	Use:   "fix",
+	Short: "Set when stale focus sessions really ended",
+	Long: `Set the real end time of focus sessions left running by mistake. Without a session, walks
+through the stale ones: open sessions that ran for longer than the idle cutoff (focus.idle_cutoff
+in the user config, 12h by default). Until fixed they count up to the cutoff only in summaries.
+The end is a time of day like 18:30, a date and time like "2026-01-02 18:30", or how long after
+the start of the session it ended, like 2h.`,
+	Example: `mindloop focus fix
+	mindloop focus fix "work on proj" --end 18:30`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var sessions []models.FocusSession
+		if len(args) == 1 {
+			id, err := focusService.Resolve(args[0])
+			if err != nil {
+				PrintErrorln("Focus session not found:", err)
+				return
+			}
+			session, err := focusService.GetSession(id)
+			if err != nil {
+				PrintErrorln("Error loading focus session:", err)
+				return
+			}
+			sessions = append(sessions, *session)
+		} else {
+			stale, err := focusService.ListStale(time.Now())
+			if err != nil {
+				PrintErrorln("Error listing stale focus sessions:", err)
+				ac.Logger.Error().Msgf("Error listing stale focus sessions: %v", err)
+				return
+			}
+			if len(stale) == 0 {
+				PrintSuccessln("No stale focus sessions, all good!")
+				return
+			}
+			sessions = stale
+		}
+
+		loc := ac.Calendar().Location
+		reader := bufio.NewReader(os.Stdin)
+		for _, session := range sessions {
+			fmt.Printf("\n'%s' (id %d) started %s, %s of focus recorded.\n", session.Title, session.ID,
+				session.CreatedAt.In(loc).Format("Mon 02 Jan 15:04"), FormatMinutes(session.ActiveMinutes(time.Now())))
+			var end time.Time
+			for {
+				input := *focusFixEnd
+				if input == "" {
+					input = ask(reader, "When did it really end? (e.g. 18:30, 2026-01-02 18:30 or 2h, Enter to skip): ")
+					if input == "" {
+						PrintInfoln("Skipped.")
+						break
+					}
+				}
+				var err error
+				if end, err = focus.ParseEnd(input, session, loc); err == nil {
+					break
+				}
+				PrintWarnln(err)
+				if *focusFixEnd != "" {
+					return
+				}
+			}
+			if end.IsZero() {
+				continue
+			}
+
+			fixed, err := focusService.FixSession(int(session.ID), end)
+			if err != nil {
+				if errors.Is(err, focus.ErrInvalidEnd) {
+					PrintWarnln("Please pick an end between the start of the session and now.")
+					continue
+				}
+				PrintErrorln("Error fixing focus session:", err)
+				ac.Logger.Error().Msgf("Error fixing focus session: %v", err)
+				continue
+			}
+			PrintSuccessf("Focus session '%s' ended at %s after %s of focus.\n", fixed.Title,
+				fixed.EndTime.In(loc).Format("Mon 02 Jan 15:04"), FormatMinutes(fixed.Duration))
+			ac.Logger.Info().Msgf("Focus session '%s' fixed to end at %s", fixed.Title, fixed.EndTime)
+		}
+	},
+}
+
 var focusRateCmd = &cobra.Command{
 	Use:     "rate",
 	Short:   "Rate a focus session",
 	Long:    `Rate a completed focus session to provide feedback on your productivity.`,
-	Example: `mindloop focus rate <session_id> <rating 0-10>`,
+	Example: `mindloop focus rate <session_id|title> <rating 0-10>`,
 	Args:    cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
-		sessionID := args[0]
-		sessionIDInt, err := strconv.Atoi(sessionID)
+		sessionIDInt, err := focusService.Resolve(args[0])
 		if err != nil {
-			PrintErrorln("Error parsing session ID:", err)
+			PrintErrorln("Focus session not found:", err)
 			return
 		}
 
@@ -131,11 +324,116 @@ var focusRateCmd = &cobra.Command{
 	},
 }
 
+// runPomodoro runs a pomodoro session in the foreground, counting down each
+// interval and ringing the terminal bell when it is over. Ctrl-C ends the
+// session, the running work interval counts as focus but not as a pomodoro.
+func runPomodoro(ctx context.Context, title string, plan focus.Pomodoro, opts focus.StartOptions) {
+	session, err := focusService.StartPomodoro(title, plan, opts)
+	if err != nil {
+		PrintErrorln("Error starting focus session:", err)
+		ac.Logger.Error().Msgf("Error starting pomodoro session: %v", err)
+		return
+	}
+	ac.Logger.Info().Msgf("Pomodoro session '%s' started with id %d, plan %s", session.Title, session.ID, plan)
+	PrintRocketf("Pomodoro '%s' started: %d round(s) of %s work and %s break. Ctrl-C to stop.\n",
+		session.Title, plan.Rounds, FormatMinutes(plan.Work.Minutes()), FormatMinutes(plan.Break.Minutes()))
+	printSessionIntent(session)
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	id := int(session.ID)
+	for round := 1; round <= plan.Rounds; round++ {
+		if !countdown(ctx, fmt.Sprintf("🍅 Round %d/%d, work", round, plan.Rounds), plan.Work) {
+			break
+		}
+		last := round == plan.Rounds
+		if _, err := focusService.CompleteInterval(id, !last); err != nil {
+			PrintErrorln("Error recording pomodoro:", err)
+			break
+		}
+		if last {
+			break
+		}
+		if !countdown(ctx, fmt.Sprintf("☕ Round %d/%d, break", round, plan.Rounds), plan.Break) {
+			break
+		}
+		if _, err := focusService.CompleteInterval(id, true); err != nil {
+			PrintErrorln("Error recording pomodoro:", err)
+			break
+		}
+	}
+
+	session, err = focusService.EndSession(id)
+	if err != nil {
+		PrintErrorln("Error ending focus session:", err)
+		ac.Logger.Error().Msgf("Error ending pomodoro session: %v", err)
+		return
+	}
+	ac.Logger.Info().Msgf("Pomodoro session '%s' ended with %d pomodoro(s)", session.Title, session.Pomodoros())
+	PrintSuccessf("Focus session '%s' ended: %d/%d pomodoro(s), %s of focus.\n",
+		session.Title, session.Pomodoros(), plan.Rounds, FormatMinutes(session.Duration))
+}
+
+// warnStale flags the sessions left running past the idle cutoff
+func warnStale() {
+	stale, err := focusService.ListStale(time.Now())
+	if err != nil {
+		ac.Logger.Error().Msgf("Error listing stale focus sessions: %v", err)
+		return
+	}
+	for _, session := range stale {
+		PrintWarnf("Focus session '%s' (id %d) has been open for over %s, it only counts up to that in summaries.\n",
+			session.Title, session.ID, FormatMinutes(focusService.IdleCutoff.Minutes()))
+	}
+	if len(stale) > 0 {
+		PrintInfoln("Set when it really ended with 'mindloop focus fix'.")
+	}
+}
+
+// printSessionIntent tells which intent a new session was linked to
+func printSessionIntent(session *models.FocusSession) {
+	if session.Intent == nil {
+		return
+	}
+	PrintInfof("Working towards intent '%s' (id %d).\n", session.Intent.Name, session.Intent.ID)
+}
+
+// countdown shows the time left of an interval on a single line and rings
+// the bell at the end. It reports false if ctx was cancelled first.
+func countdown(ctx context.Context, label string, d time.Duration) bool {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	end := time.Now().Add(d)
+	for {
+		left := time.Until(end).Round(time.Second)
+		if left <= 0 {
+			fmt.Printf("\r\033[K%s done\a\n", label)
+			return true
+		}
+		fmt.Printf("\r\033[K%s: %02d:%02d left", label, int(left.Minutes()), int(left.Seconds())%60)
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
 func init() {
 	focusCmd.AddCommand(focusStartCmd)
 	focusCmd.AddCommand(focusListCmd)
 	focusCmd.AddCommand(focusEndCmd)
+	focusCmd.AddCommand(focusPauseCmd)
+	focusCmd.AddCommand(focusResumeCmd)
 	focusCmd.AddCommand(focusRateCmd)
+	focusCmd.AddCommand(focusFixCmd)
 
 	rootCmd.AddCommand(focusCmd)
+
+	focusIntent = focusStartCmd.Flags().StringP("intent", "I", "", "Intent (id or name) the session works towards, defaults to the active intent if there is only one")
+	focusFor = focusStartCmd.Flags().Duration("for", 0, "Planned duration of the session, e.g. 50m or 1h30m, it ends by itself after that")
+	focusFixEnd = focusFixCmd.Flags().StringP("end", "e", "", "When the session really ended, e.g. 18:30, \"2026-01-02 18:30\" or 2h after it started")
+	pomodoro = focusStartCmd.Flags().String("pomodoro", "", "Run the session in the foreground as pomodoros, work/break minutes and rounds, e.g. 25/5x4")
 }
@@ -2,11 +2,18 @@ package cli
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
-	"github.com/snehmatic/mindloop/internal/core/habit"
+	habitcore "github.com/snehmatic/mindloop/internal/core/habit"
+	"github.com/snehmatic/mindloop/internal/notify"
+	"github.com/snehmatic/mindloop/internal/period"
 	. "github.com/snehmatic/mindloop/internal/utils"
 	"github.com/snehmatic/mindloop/models"
 	"github.com/spf13/cobra"
@@ -16,17 +23,50 @@ var (
 	all          *bool
 	daily        *bool
 	weekly       *bool
+	monthly      *bool
+	everyN       *int
+	onWeekdays   *string
 	interactive  *bool
-	habitService *habit.Service
+	logDate      *string
+	unlogDate    *string
+	logNote      *string
+	unlogOne     *bool
+	syncFile     *string
+	syncDryRun   *bool
+	unit         *string
+	avoid        *bool
+	relapseDate  *string
+	relapseNote  *string
+	pauseFrom    *string
+	pauseUntil   *string
+	pauseReason  *string
+	skipDate     *string
+	skipReason   *string
+	unskipDate   *string
+	tags         *string
+	tagFilter    *string
+	remindAt     *string
+	window       *string
+	remindOnce   *bool
+	remindTick   *time.Duration
+	remindVia    *string
+	remindCmd    *string
+	remindURL    *string
+	infoRecent   *int
+	moveTo       *int
+	habitService *habitcore.Service
 )
 
 // parent habit command
 var habitCmd = &cobra.Command{
-	Use:     "habit",
-	Short:   "Manage your habits",
-	Example: `mindloop habit add "Excercise"`,
+	Use:   "habit",
+	Short: "Manage your habits",
+	Long: `Manage your habits.
+Habits can be referred to by ID, slug (e.g. drink-water) or a unique title prefix.`,
+	Example: `mindloop habit add "Excercise"
+	mindloop habit log gym`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
-		habitService = habit.NewService(gdb)
+		habitService = habitcore.NewService(gdb)
 	},
 }
 
@@ -35,6 +75,15 @@ var habitAddCmd = &cobra.Command{
 	Use:   "add",
 	Short: "Add a new habit",
 	Example: `mindloop habit add "Excercise" "Need to be fit!" 1 --daily
+	mindloop habit add "Review budget" "Stay on top of spending" 1 --monthly
+	mindloop habit add "Water plants" "Keep them alive" 1 --every 3
+	mindloop habit add "Gym" "Lift heavy things" 1 --on mon,wed,fri
+	mindloop habit add "Water" "Stay hydrated" 2000 --unit ml
+	mindloop habit add "No sugar" "Skip the sweets" --avoid
+	mindloop habit add "No screens" "Sleep better" --avoid --window "after 10pm"
+	mindloop habit add "Read" "Learn something" 10 --unit pages --tags learning
+	mindloop habit add "Stretch" "Loosen up" 1 --remind-at "7:30 AM"
+	mindloop habit add "Meditate" "Calm mind" 1 --window "before 9am"
 	mindloop habit add -i`,
 	Run: func(cmd *cobra.Command, args []string) {
 		PrintRocketln("Great initiative! Adding a new habit...")
@@ -46,8 +95,12 @@ var habitAddCmd = &cobra.Command{
 			BuildHabitFromInteractiveMode(newHabit)
 		} else {
 			// non interactive mode
-			if len(args) < 3 {
-				PrintWarnln("Please provide habit details. Ex. 'mindloop habit add <title> <description> <target_count>' --weekly or --daily(default)")
+			minArgs := 3
+			if *avoid {
+				minArgs = 2
+			}
+			if len(args) < minArgs {
+				PrintWarnln("Please provide habit details. Ex. 'mindloop habit add <title> <description> <target_count>' --daily(default), --weekly, --monthly, --every <n> or --on <weekdays>")
 				ac.Logger.Error().
 					Interface("habit", newHabit).
 					Msg("Failed to add habit: missing arguments")
@@ -55,17 +108,44 @@ var habitAddCmd = &cobra.Command{
 			}
 			newHabit.Title = args[0]
 			newHabit.Description = args[1]
-			targetCount, err := strconv.Atoi(args[2])
+			targetCount := 0 // habits to avoid have no target
+			var err error
+			if !*avoid {
+				targetCount, err = strconv.Atoi(args[2])
+				if err != nil {
+					ac.Logger.Error().
+						Interface("habit", newHabit).
+						Err(err).
+						Msg("Failed to convert target count to integer")
+					PrintErrorln("Invalid target count. Please provide a valid integer.")
+					return
+				}
+			}
+			newHabit.TargetCount = targetCount
+			newHabit.Unit = strings.TrimSpace(*unit)
+			newHabit.Tags = models.FormatTags(models.ParseTags(*tags))
+			if *remindAt != "" {
+				at, err := models.ParseTimeOfDay(*remindAt)
+				if err != nil {
+					PrintErrorln(err)
+					return
+				}
+				newHabit.RemindAt = at
+			}
+			newHabit.WindowStart, newHabit.WindowEnd, err = models.ParseWindow(*window)
 			if err != nil {
-				ac.Logger.Error().
-					Interface("habit", newHabit).
-					Err(err).
-					Msg("Failed to convert target count to integer")
-				PrintErrorln("Invalid target count. Please provide a valid integer.")
+				PrintErrorln(err)
 				return
 			}
-			newHabit.TargetCount = targetCount
+			if *avoid {
+				newHabit.Kind = models.KindAvoid
+			}
 			newHabit.Interval = GetIntervalFromFlag()
+			newHabit.EveryN = *everyN
+			newHabit.Weekdays = *onWeekdays
+			if days, err := models.ParseWeekdays(*onWeekdays); err == nil {
+				newHabit.Weekdays = models.FormatWeekdays(days)
+			}
 		}
 
 		// Service call replaces direct validation and creation
@@ -97,7 +177,7 @@ var habitDeleteCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) < 1 {
 			ac.Logger.Error().Msg("No habit ID provided for deletion")
-			PrintWarnln("Please provide the habit ID to delete.")
+			PrintWarnln("Please provide the habit ID or name to delete.")
 			return
 		}
 		habitID := args[0]
@@ -113,7 +193,7 @@ var habitDeleteCmd = &cobra.Command{
 			return
 		}
 
-		err = habitService.DeleteHabit(habitID)
+		err = habitService.DeleteHabit(fmt.Sprint(habit.ID))
 		if err != nil {
 			ac.Logger.Error().Err(err).Msg("Failed to delete habit")
 			PrintErrorln("Failed to delete habit:", err)
@@ -132,11 +212,11 @@ var habitUpdateCmd = &cobra.Command{
 	Use:     "update",
 	Short:   "Update a habit",
 	Aliases: []string{"edit", "modify"},
-	Example: `mindloop habit update "Excercise" --time "1:00 PM"`,
+	Example: `mindloop habit update "Excercise"`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) < 1 {
 			ac.Logger.Error().Msg("No habit ID provided for update")
-			PrintWarnln("Please provide the habit ID to update.")
+			PrintWarnln("Please provide the habit ID or name to update.")
 			return
 		}
 		habitId := args[0]
@@ -169,36 +249,50 @@ var habitUpdateCmd = &cobra.Command{
 			Interface("habit", habit).
 			Msg("Habit updated successfully")
 		PrintSuccessf("Habit '%s' updated successfully.\n", habit.Title)
+		PrintInfoln("Changes apply from today on, past periods keep the previous definition.")
 	},
 }
 
 var habitListCmd = &cobra.Command{
-	Use:     "list",
-	Short:   "List all habits",
-	Example: `mindloop habit list`,
+	Use:   "list",
+	Short: "List all habits",
+	Example: `mindloop habit list
+	mindloop habit list --all
+	mindloop habit list --tag health`,
 	Aliases: []string{"l"},
 	Run: func(cmd *cobra.Command, args []string) {
 		PrintInfoln("Keep calm, fetching habits...")
 		ac.Logger.Info().Msg("Fetching habits...")
 
 		intervalFilter := models.IntervalType("")
-		if !*daily && !*weekly { // nothing selected via flags
+		if !IntervalFlagSet() { // nothing selected via flags
 			PrintInfoln("No interval filter applied. Showing all habit logs.")
 			ac.Logger.Info().Msg("No interval filter applied. Showing all habit logs.")
 		} else {
 			intervalFilter = GetIntervalFromFlag()
 		}
 
-		habits, err := habitService.ListHabits(intervalFilter)
+		habits, err := habitService.ListHabits(habitcore.ListOptions{Interval: intervalFilter, Tag: *tagFilter, IncludeArchived: *all})
 		if err != nil {
 			ac.Logger.Error().Err(err).Msg("Failed to retrieve habits")
 			PrintErrorln("Failed to retrieve habits:", err)
 			return
 		}
 
+		cal := ac.Calendar()
+		today := cal.Day(time.Now())
 		var habitViews []models.HabitView
 		for _, habit := range habits {
-			habitViews = append(habitViews, models.ToHabitView(habit))
+			hv := models.ToHabitView(habit)
+			if streak, err := habitService.GetStreak(&habit); err != nil {
+				ac.Logger.Error().Err(err).Uint("habit_id", habit.ID).Msg("Failed to calculate habit streak")
+			} else {
+				hv = models.ToHabitViewWithStreak(habit, streak)
+			}
+			if pause := habit.PauseCovering(cal, today); pause != nil && !habit.IsArchived() {
+				hv.Status = pause.Label()
+			}
+			habitViews = append(habitViews, hv)
 		}
 		PrintTable(habitViews)
 	},
@@ -209,21 +303,54 @@ var habitLogCmd = &cobra.Command{
 	Use:     "log",
 	Aliases: []string{"done", "complete", "mkd"},
 	Short:   "Log a habit as done",
-	Example: `mindloop habit log "Excercise"`,
+	Example: `mindloop habit log "Excercise"
+	mindloop habit log gym
+	mindloop habit log water 250
+	mindloop habit log 3 --date 2026-10-14
+	mindloop habit log run -n "5k in 28min"`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) < 1 {
 			ac.Logger.Error().Msg("No habit ID provided for logging")
-			PrintWarnln("Please provide the habit ID to log.")
+			PrintWarnln("Please provide the habit ID or name to log.")
 			return
 		}
 		habitID := args[0]
 
-		habit, log, err := habitService.LogHabit(habitID)
+		date, err := ParseDateFlag(*logDate)
+		if err != nil {
+			PrintWarnln("Invalid date. Please use the YYYY-MM-DD format, 'today' or 'yesterday'.")
+			return
+		}
+
+		amount := 0 // defaults to a single check-in
+		if len(args) > 1 {
+			amount, err = strconv.Atoi(args[1])
+			if err != nil || amount <= 0 {
+				PrintWarnln("Invalid amount. Please provide a positive whole number, e.g. 'mindloop habit log water 250'.")
+				return
+			}
+		}
+
+		if habit, err := habitService.GetHabit(habitID); err == nil && habit.IsAvoid() {
+			PrintInfof("'%s' is a habit to avoid, there is nothing to log while you stay clean.\n", habit.Title)
+			PrintInfof("Use 'mindloop habit relapse %d' if you slipped.\n", habit.ID)
+			return
+		}
+
+		habit, log, err := habitService.LogHabit(habitID, habitcore.LogOptions{Date: date, Amount: amount, Note: *logNote})
 		if err != nil {
-			if err.Error() == "habit already completed for interval" {
+			if errors.Is(err, habitcore.ErrAlreadyCompleted) {
 				PrintRocketf("Habit already completed. No need to log again.\n")
 				return
 			}
+			if errors.Is(err, habitcore.ErrArchived) {
+				PrintWarnf("Habit '%s' is archived. Use 'mindloop habit unarchive %d' to bring it back.\n", habit.Title, habit.ID)
+				return
+			}
+			if errors.Is(err, habitcore.ErrNotScheduled) {
+				PrintInfof("Habit '%s' is scheduled for %s, enjoy the day off!\n", habit.Title, habit.ScheduleLabel())
+				return
+			}
 			ac.Logger.Error().Err(err).Msg("Failed to log habit")
 			PrintErrorln("Failed to log habit:", err)
 			return
@@ -231,29 +358,89 @@ var habitLogCmd = &cobra.Command{
 
 		ac.Logger.Info().
 			Interface("habit", habit).
-			Msgf("Habit %s logged %d/%d times in %s interval", habit.Title, log.ActualCount, habit.TargetCount, habit.Interval)
-		PrintLoadingf("Habit %s logged %d/%d times in %s interval.\n", habit.Title, log.ActualCount, habit.TargetCount, habit.Interval)
-		PrintInfof("Use 'mindloop habit unlog <id>' to mark it as undone, and reset to 0/%d.\n", habit.TargetCount)
+			Msgf("Habit %s logged %d/%d %s in %s interval", habit.Title, log.ActualCount, habit.TargetCount, habit.Unit, habit.Interval)
+		if habit.IsMeasurable() {
+			PrintLoadingf("Habit %s at %d/%s in %s interval (%s to %s).\n", habit.Title, log.ActualCount, habit.FormatAmount(habit.TargetCount), habit.Interval,
+				log.StartedAt.Format("2006-01-02"), log.EndedAt.Format("2006-01-02"))
+		} else {
+			PrintLoadingf("Habit %s logged %d/%d times in %s interval (%s to %s).\n", habit.Title, log.ActualCount, habit.TargetCount, habit.Interval,
+				log.StartedAt.Format("2006-01-02"), log.EndedAt.Format("2006-01-02"))
+		}
+		if habitcore.IsLate(ac.Calendar(), habit, period.Period{Start: log.StartedAt, End: log.EndedAt}, time.Now()) {
+			PrintWarnf("Logged outside the time window (%s), it counts as late.\n", habit.WindowLabel())
+		}
+		PrintInfof("Use 'mindloop habit unlog <id> --one' to undo this log, or without --one to reset to 0/%d.\n", habit.TargetCount)
 		PrintSuccessf("Habit '%s' logged successfully.\n", habit.Title)
 	},
 }
 
+// relapse subcommand for habits to avoid
+var habitRelapseCmd = &cobra.Command{
+	Use:     "relapse",
+	Aliases: []string{"slip"},
+	Short:   "Log a relapse of a habit to avoid",
+	Args:    cobra.ExactArgs(1),
+	Example: `mindloop habit relapse no-sugar
+	mindloop habit relapse no-sugar --date yesterday -n "birthday cake"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		date, err := ParseDateFlag(*relapseDate)
+		if err != nil {
+			PrintWarnln("Invalid date. Please use the YYYY-MM-DD format, 'today' or 'yesterday'.")
+			return
+		}
+
+		habit, err := habitService.GetHabit(args[0])
+		if err != nil {
+			PrintErrorln("Habit not found:", err)
+			return
+		}
+		if !habit.IsAvoid() {
+			PrintWarnf("'%s' is not a habit to avoid. Use 'mindloop habit log %d' to log it.\n", habit.Title, habit.ID)
+			return
+		}
+
+		habit, log, err := habitService.LogHabit(fmt.Sprint(habit.ID), habitcore.LogOptions{Date: date, Note: *relapseNote})
+		if err != nil {
+			ac.Logger.Error().Err(err).Msg("Failed to log relapse")
+			PrintErrorln("Failed to log relapse:", err)
+			return
+		}
+
+		ac.Logger.Info().
+			Interface("habit", habit).
+			Msgf("Relapse of %s logged, %d in %s interval", habit.Title, log.Relapses(), habit.Interval)
+		PrintInfof("Relapse logged for '%s' (%d this period). Tomorrow is a new day!\n", habit.Title, log.Relapses())
+		if log.LateCount > 0 {
+			PrintInfof("%d relapse(s) outside the time window (%s) don't count.\n", log.LateCount, habit.WindowLabel())
+		}
+		PrintInfoln("Use 'mindloop habit unlog <id> --one' if it was logged by mistake.")
+	},
+}
+
 // log habit as done subcommand
 var habitUnLogCmd = &cobra.Command{
 	Use:     "unlog",
 	Aliases: []string{"undone", "incomplete", "mkud"},
 	Args:    cobra.ExactArgs(1),
 	Short:   "Log a habit as undone",
-	Example: `mindloop habit unlog "Excercise"`,
+	Example: `mindloop habit unlog "Excercise"
+	mindloop habit unlog water --one
+	mindloop habit unlog 3 --date yesterday`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) < 1 {
 			ac.Logger.Error().Msg("No habit ID provided for unlogging")
-			PrintWarnln("Please provide the habit ID to unlog.")
+			PrintWarnln("Please provide the habit ID or name to unlog.")
 			return
 		}
 		habitID := args[0]
 
-		habit, err := habitService.UnlogHabit(habitID)
+		date, err := ParseDateFlag(*unlogDate)
+		if err != nil {
+			PrintWarnln("Invalid date. Please use the YYYY-MM-DD format, 'today' or 'yesterday'.")
+			return
+		}
+
+		habit, log, err := habitService.UnlogHabit(habitID, habitcore.LogOptions{Date: date, LastOnly: *unlogOne})
 		if err != nil {
 			ac.Logger.Error().Err(err).Msg("Failed to unlog habit")
 			PrintErrorln("Failed to unlog habit:", err)
@@ -263,11 +450,524 @@ var habitUnLogCmd = &cobra.Command{
 		ac.Logger.Info().
 			Interface("habit", habit).
 			Msg("Habit unlogged successfully")
-		PrintSuccessf("Habit '%s' unlogged successfully. Reset to 0/%d.\n", habit.Title, habit.TargetCount)
+		PrintSuccessf("Habit '%s' unlogged successfully. Now at %d/%s.\n", habit.Title, log.ActualCount, habit.FormatAmount(habit.TargetCount))
 		PrintInfoln("Use 'mindloop habit log <id>' to mark it as done again.")
 	},
 }
 
+// habit sync subcommand
+var habitSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync habits with a habit manifest file",
+	Long: `Create, update and archive habits to match a YAML habit manifest (default: .mlrc).
+Habits are matched by slug. Habits that were synced before and are no longer listed get archived.
+
+Example manifest:
+  habits:
+    - title: Drink water
+      target: 2000
+      unit: ml
+    - title: Gym
+      interval: weekdays
+      weekdays: [mon, wed, fri]`,
+	Example: `mindloop habit sync --dry-run
+	mindloop habit sync --file team-habits.yaml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		changes, err := habitService.SyncFromFile(*syncFile, *syncDryRun)
+		if err != nil {
+			ac.Logger.Error().Err(err).Msg("Failed to sync habits")
+			PrintErrorln("Failed to sync habits:", err)
+			return
+		}
+		if len(changes) == 0 {
+			PrintSuccessln("Habits are already in sync with the manifest.")
+			return
+		}
+
+		symbols := map[habitcore.SyncAction]string{
+			habitcore.SyncCreate:  "+",
+			habitcore.SyncUpdate:  "~",
+			habitcore.SyncRestore: "~",
+			habitcore.SyncArchive: "-",
+		}
+		for _, change := range changes {
+			fmt.Printf("%s %s %s\n", symbols[change.Action], change.Action, change.Title)
+			for _, line := range change.Diff {
+				fmt.Printf("    %s\n", line)
+			}
+		}
+
+		ac.Logger.Info().
+			Interface("changes", changes).
+			Bool("dry_run", *syncDryRun).
+			Msg("Habit sync finished")
+		if *syncDryRun {
+			PrintInfof("Dry run, %d change(s) not applied. Run without --dry-run to apply them.\n", len(changes))
+			return
+		}
+		PrintSuccessf("Applied %d change(s) from the habit manifest.\n", len(changes))
+	},
+}
+
+// habit history subcommand
+var habitHistoryCmd = &cobra.Command{
+	Use:     "history",
+	Aliases: []string{"events"},
+	Short:   "Show every log of a habit with the time it was made",
+	Args:    cobra.ExactArgs(1),
+	Example: `mindloop habit history water`,
+	Run: func(cmd *cobra.Command, args []string) {
+		habit, events, err := habitService.ListEvents(args[0])
+		if err != nil {
+			ac.Logger.Error().Err(err).Msg("Failed to retrieve habit history")
+			PrintErrorln("Failed to retrieve habit history:", err)
+			return
+		}
+		if len(events) == 0 {
+			PrintInfof("No logs for '%s' yet. Start with 'mindloop habit log %d'\n", habit.Title, habit.ID)
+			return
+		}
+		PrintTable(models.ToHabitEventViews(*habit, events))
+	},
+}
+
+// search habit log notes subcommand
+var habitSearchCmd = &cobra.Command{
+	Use:     "search",
+	Aliases: []string{"grep", "find"},
+	Short:   "Search the notes of habit logs",
+	Args:    cobra.MinimumNArgs(1),
+	Example: `mindloop habit search 5k
+	mindloop habit search "personal best" --tag health`,
+	Run: func(cmd *cobra.Command, args []string) {
+		query := strings.Join(args, " ")
+		events, err := habitService.SearchNotes(query, habitcore.ListOptions{Tag: *tagFilter})
+		if err != nil {
+			ac.Logger.Error().Err(err).Msg("Failed to search habit notes")
+			PrintErrorln("Failed to search habit notes:", err)
+			return
+		}
+		if len(events) == 0 {
+			PrintInfof("No notes matching %q. Add one with 'mindloop habit log <id> -n \"...\"'\n", query)
+			return
+		}
+		PrintTable(models.ToHabitNoteViews(events))
+	},
+}
+
+// archive habit subcommand
+var habitArchiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Archive a habit",
+	Long: `Archive a habit you no longer track.
+Archived habits are hidden from 'habit list' but their history is kept and still shows up in summaries.`,
+	Args:    cobra.ExactArgs(1),
+	Example: `mindloop habit archive gym`,
+	Run: func(cmd *cobra.Command, args []string) {
+		habit, err := habitService.ArchiveHabit(args[0])
+		if err != nil {
+			if errors.Is(err, habitcore.ErrArchived) {
+				PrintInfof("Habit '%s' is already archived.\n", habit.Title)
+				return
+			}
+			ac.Logger.Error().Err(err).Msg("Failed to archive habit")
+			PrintErrorln("Failed to archive habit:", err)
+			return
+		}
+
+		ac.Logger.Info().
+			Interface("habit", habit).
+			Msg("Habit archived successfully")
+		PrintSuccessf("Habit '%s' archived. Use 'mindloop habit list --all' to see archived habits.\n", habit.Title)
+	},
+}
+
+// unarchive habit subcommand
+var habitUnarchiveCmd = &cobra.Command{
+	Use:     "unarchive",
+	Short:   "Bring back an archived habit",
+	Args:    cobra.ExactArgs(1),
+	Example: `mindloop habit unarchive gym`,
+	Run: func(cmd *cobra.Command, args []string) {
+		habit, err := habitService.UnarchiveHabit(args[0])
+		if err != nil {
+			if errors.Is(err, habitcore.ErrNotArchived) {
+				PrintInfof("Habit '%s' is not archived.\n", habit.Title)
+				return
+			}
+			ac.Logger.Error().Err(err).Msg("Failed to unarchive habit")
+			PrintErrorln("Failed to unarchive habit:", err)
+			return
+		}
+
+		ac.Logger.Info().
+			Interface("habit", habit).
+			Msg("Habit unarchived successfully")
+		PrintSuccessf("Habit '%s' is back, the days it spent archived don't count as missed.\n", habit.Title)
+	},
+}
+
+// move habit subcommand
+var habitMoveCmd = &cobra.Command{
+	Use:   "move",
+	Short: "Move a habit up or down the list",
+	Long: `Move a habit to another position in lists and summaries, 1 being the top.
+Pinned habits always stay ahead of the others.`,
+	Args: cobra.ExactArgs(1),
+	Example: `mindloop habit move meditate --to 1
+	mindloop habit move 4 -t 2`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if *moveTo < 1 {
+			PrintWarnln("Please provide the position to move the habit to, e.g. --to 1")
+			return
+		}
+		habit, position, err := habitService.MoveHabit(args[0], *moveTo)
+		if err != nil {
+			if errors.Is(err, habitcore.ErrArchived) {
+				PrintWarnf("Habit '%s' is archived. Use 'mindloop habit unarchive %d' to bring it back.\n", habit.Title, habit.ID)
+				return
+			}
+			ac.Logger.Error().Err(err).Msg("Failed to move habit")
+			PrintErrorln("Failed to move habit:", err)
+			return
+		}
+
+		ac.Logger.Info().Interface("habit", habit).Msg("Habit moved successfully")
+		if position > *moveTo && !habit.Pinned {
+			PrintInfof("Pinned habits stay ahead of the others, '%s' went as far up as it could.\n", habit.Title)
+		}
+		PrintSuccessf("Habit '%s' moved to position %d.\n", habit.Title, position)
+	},
+}
+
+// pin habit subcommand
+var habitPinCmd = &cobra.Command{
+	Use:     "pin",
+	Short:   "Pin a habit to the top of lists",
+	Args:    cobra.ExactArgs(1),
+	Example: `mindloop habit pin meditate`,
+	Run: func(cmd *cobra.Command, args []string) {
+		habit, err := habitService.PinHabit(args[0], true)
+		if err != nil {
+			ac.Logger.Error().Err(err).Msg("Failed to pin habit")
+			PrintErrorln("Failed to pin habit:", err)
+			return
+		}
+		PrintSuccessf("Habit '%s' pinned to the top.\n", habit.Title)
+	},
+}
+
+// unpin habit subcommand
+var habitUnpinCmd = &cobra.Command{
+	Use:     "unpin",
+	Short:   "Unpin a habit",
+	Args:    cobra.ExactArgs(1),
+	Example: `mindloop habit unpin meditate`,
+	Run: func(cmd *cobra.Command, args []string) {
+		habit, err := habitService.PinHabit(args[0], false)
+		if err != nil {
+			ac.Logger.Error().Err(err).Msg("Failed to unpin habit")
+			PrintErrorln("Failed to unpin habit:", err)
+			return
+		}
+		PrintSuccessf("Habit '%s' unpinned, it's back at its position.\n", habit.Title)
+	},
+}
+
+// pause habit subcommand
+var habitPauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Pause a habit over a range of days, e.g. a vacation",
+	Long: `Pause a habit over a range of days, e.g. a vacation.
+Paused periods don't count towards completion rates and don't break streaks.
+Without --until the habit stays paused until 'mindloop habit resume'.`,
+	Args: cobra.ExactArgs(1),
+	Example: `mindloop habit pause gym
+	mindloop habit pause gym --from 2026-12-20 --until 2027-01-03 --reason vacation`,
+	Run: func(cmd *cobra.Command, args []string) {
+		from, err := ParseDateFlag(*pauseFrom)
+		if err != nil {
+			PrintWarnln("Invalid --from date. Please use the YYYY-MM-DD format, 'today' or 'yesterday'.")
+			return
+		}
+		until, err := ParseDateFlag(*pauseUntil)
+		if err != nil {
+			PrintWarnln("Invalid --until date. Please use the YYYY-MM-DD format.")
+			return
+		}
+		if until.IsZero() && *pauseUntil != "" { // --until today
+			until = time.Now()
+		}
+
+		habit, pause, err := habitService.PauseHabit(args[0], from, until, *pauseReason)
+		if err != nil {
+			ac.Logger.Error().Err(err).Msg("Failed to pause habit")
+			PrintErrorln("Failed to pause habit:", err)
+			return
+		}
+
+		ac.Logger.Info().
+			Interface("habit", habit).
+			Interface("pause", pause).
+			Msg("Habit paused successfully")
+		PrintSuccessf("Habit '%s' %s, starting %s.\n", habit.Title, pause.Label(), pause.StartedAt.Format("2006-01-02"))
+		PrintInfoln("Use 'mindloop habit resume <id>' to pick it up again.")
+	},
+}
+
+// resume habit subcommand
+var habitResumeCmd = &cobra.Command{
+	Use:     "resume",
+	Short:   "Resume a paused habit",
+	Args:    cobra.ExactArgs(1),
+	Example: `mindloop habit resume gym`,
+	Run: func(cmd *cobra.Command, args []string) {
+		habit, err := habitService.ResumeHabit(args[0])
+		if err != nil {
+			if errors.Is(err, habitcore.ErrNotPaused) {
+				PrintInfof("Habit '%s' is not paused today.\n", habit.Title)
+				return
+			}
+			ac.Logger.Error().Err(err).Msg("Failed to resume habit")
+			PrintErrorln("Failed to resume habit:", err)
+			return
+		}
+
+		ac.Logger.Info().
+			Interface("habit", habit).
+			Msg("Habit resumed successfully")
+		PrintSuccessf("Habit '%s' resumed, it is due again from today.\n", habit.Title)
+	},
+}
+
+// skip habit subcommand
+var habitSkipCmd = &cobra.Command{
+	Use:     "skip",
+	Aliases: []string{"excuse"},
+	Short:   "Skip a period of a habit, e.g. when sick",
+	Long: `Skip a period of a habit, e.g. when sick.
+Skipped periods don't count towards completion rates and don't break streaks.
+Logging the habit for the period takes the skip back.`,
+	Args: cobra.ExactArgs(1),
+	Example: `mindloop habit skip gym --reason sick
+	mindloop habit skip 3 --date yesterday --reason travel`,
+	Run: func(cmd *cobra.Command, args []string) {
+		date, err := ParseDateFlag(*skipDate)
+		if err != nil {
+			PrintWarnln("Invalid date. Please use the YYYY-MM-DD format, 'today' or 'yesterday'.")
+			return
+		}
+
+		habit, log, err := habitService.SkipHabit(args[0], date, strings.TrimSpace(*skipReason))
+		if err != nil {
+			if errors.Is(err, habitcore.ErrAlreadyCompleted) {
+				PrintRocketf("Habit already completed for that period, nothing to skip.\n")
+				return
+			}
+			if errors.Is(err, habitcore.ErrNotScheduled) {
+				PrintInfof("Habit '%s' is scheduled for %s, that day is off anyway.\n", habit.Title, habit.ScheduleLabel())
+				return
+			}
+			ac.Logger.Error().Err(err).Msg("Failed to skip habit")
+			PrintErrorln("Failed to skip habit:", err)
+			return
+		}
+
+		ac.Logger.Info().
+			Interface("habit", habit).
+			Interface("log", log).
+			Msg("Habit skipped successfully")
+		PrintSuccessf("Habit '%s' %s for %s to %s.\n", habit.Title, log.SkipLabel(),
+			log.StartedAt.Format("2006-01-02"), log.EndedAt.Format("2006-01-02"))
+		PrintInfoln("Use 'mindloop habit unskip <id>' to take it back.")
+	},
+}
+
+// unskip habit subcommand
+var habitUnskipCmd = &cobra.Command{
+	Use:   "unskip",
+	Short: "Take back the skip of a habit period",
+	Args:  cobra.ExactArgs(1),
+	Example: `mindloop habit unskip gym
+	mindloop habit unskip 3 --date yesterday`,
+	Run: func(cmd *cobra.Command, args []string) {
+		date, err := ParseDateFlag(*unskipDate)
+		if err != nil {
+			PrintWarnln("Invalid date. Please use the YYYY-MM-DD format, 'today' or 'yesterday'.")
+			return
+		}
+
+		habit, _, err := habitService.UnskipHabit(args[0], date)
+		if err != nil {
+			if errors.Is(err, habitcore.ErrNotSkipped) {
+				PrintInfof("Habit '%s' is not skipped for that period.\n", habit.Title)
+				return
+			}
+			ac.Logger.Error().Err(err).Msg("Failed to unskip habit")
+			PrintErrorln("Failed to unskip habit:", err)
+			return
+		}
+
+		ac.Logger.Info().
+			Interface("habit", habit).
+			Msg("Habit unskipped successfully")
+		PrintSuccessf("Habit '%s' is due again for that period.\n", habit.Title)
+	},
+}
+
+var habitRemindCmd = &cobra.Command{
+	Use:   "remind",
+	Short: "Send reminders for habits not done yet",
+	Long: `Send reminders for habits that have a reminder time and are not done yet in their current period.
+Runs until interrupted, each habit is reminded at most once a day.
+Reminders are printed by default. Set reminders.notifier in the user config to "command" (with reminders.command)
+or "webhook" (with reminders.webhook_url) to deliver them elsewhere.`,
+	Example: `mindloop habit remind
+	mindloop habit remind --once
+	mindloop habit remind --interval 5m
+	mindloop habit remind --notifier command --command 'notify-send "$MINDLOOP_TITLE" "$MINDLOOP_MESSAGE"'
+	mindloop habit remind --notifier webhook --webhook http://localhost:9000/hooks/mindloop`,
+	Run: func(cmd *cobra.Command, args []string) {
+		rc := ac.Reminders
+		if *remindVia != "" {
+			rc.Notifier = *remindVia
+		}
+		if *remindCmd != "" {
+			rc.Command = *remindCmd
+		}
+		if *remindURL != "" {
+			rc.WebhookURL = *remindURL
+		}
+		notifier, err := notify.FromConfig(rc)
+		if err != nil {
+			ac.Logger.Error().Err(err).Msg("Invalid reminder notifier")
+			PrintErrorln("Invalid reminder notifier:", err)
+			return
+		}
+		scheduler := habitcore.NewScheduler(habitService, notifier)
+
+		if *remindOnce {
+			sent, err := scheduler.Tick(cmd.Context())
+			if err != nil {
+				ac.Logger.Error().Err(err).Msg("Failed to send reminders")
+				PrintErrorln("Failed to send reminders:", err)
+			}
+			PrintInfof("%d reminder(s) sent.\n", len(sent))
+			return
+		}
+
+		if *remindTick <= 0 {
+			PrintWarnln("Invalid interval. Please use a positive duration, e.g. 1m or 30s.")
+			return
+		}
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		PrintInfof("Checking for due reminders every %s, press Ctrl+C to stop.\n", *remindTick)
+		scheduler.Run(ctx, *remindTick, func(err error) {
+			ac.Logger.Error().Err(err).Msg("Failed to send reminders")
+			PrintErrorln("Failed to send reminders:", err)
+		})
+	},
+}
+
+var habitInfoCmd = &cobra.Command{
+	Use:     "info",
+	Aliases: []string{"details", "inspect"},
+	Short:   "Show everything about a habit",
+	Long: `Show the definition of a habit with its full history: completion overall and by month, streaks,
+best and worst weekday and the most recent logs.`,
+	Args: cobra.ExactArgs(1),
+	Example: `mindloop habit info gym
+	mindloop habit info water --recent 20`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if *infoRecent < 0 {
+			PrintWarnln("Invalid --recent. Please provide a number of logs of 0 or more.")
+			return
+		}
+		a, err := habitService.Analytics(args[0], *infoRecent)
+		if err != nil {
+			ac.Logger.Error().Err(err).Msg("Failed to analyze habit")
+			PrintErrorln("Failed to analyze habit:", err)
+			return
+		}
+		h := a.Habit
+		PrintTable([]models.HabitView{models.ToHabitViewWithStreak(h, a.Streak)})
+
+		fmt.Printf("Created:        %s\n", h.CreatedAt.In(ac.Location).Format("2006-01-02"))
+		if h.IsAvoid() {
+			fmt.Printf("Clean periods:  %d of %d (%.0f%%), %d relapse(s) in total\n", a.Completed, a.PeriodsDue, a.CompletionRate, a.CheckIns)
+			fmt.Printf("Last relapse:   %s\n", formatDay(a.Streak.LastRelapse, "never"))
+		} else {
+			fmt.Printf("Completed:      %d of %d periods (%.0f%%), %s logged in total\n", a.Completed, a.PeriodsDue, a.CompletionRate, h.FormatAmount(a.CheckIns))
+			fmt.Printf("Last done:      %s\n", formatDay(a.Streak.LastCompleted, "never"))
+		}
+		switch {
+		case h.HasWindow() && h.IsAvoid():
+			fmt.Printf("Time window:    %s, relapses outside it don't count\n", h.WindowLabel())
+		case h.HasWindow():
+			fmt.Printf("Time window:    %s, %d of %d completions on time\n", h.WindowLabel(), a.CompletedOnTime, a.Completed)
+		}
+		fmt.Printf("Streak:         %d (best %d)\n", a.Streak.Current, a.Streak.Longest)
+		if a.BestWeekday != nil {
+			fmt.Printf("Best weekday:   %s\n", formatWeekday(a, *a.BestWeekday))
+			fmt.Printf("Worst weekday:  %s\n", formatWeekday(a, *a.WorstWeekday))
+		}
+
+		if len(a.Months) > 0 {
+			fmt.Println("\nBy month")
+			PrintTable(models.ToMonthStatsViews(a.Months))
+		}
+		if len(a.Recent) > 0 {
+			fmt.Println("\nRecent logs")
+			PrintTable(a.Recent)
+		}
+	},
+}
+
+// formatDay formats a date, or returns none if it is zero
+func formatDay(t time.Time, none string) string {
+	if t.IsZero() {
+		return none
+	}
+	return t.Format("2006-01-02")
+}
+
+// formatWeekday describes how the habit does on a weekday, e.g. "Monday (90% of 10 days)"
+func formatWeekday(a *models.HabitAnalytics, ws models.WeekdayStats) string {
+	switch {
+	case !a.ByCheckIns:
+		return fmt.Sprintf("%s (%.0f%% of %d days)", ws.Weekday, ws.Rate(), ws.Due)
+	case a.Habit.IsAvoid():
+		return fmt.Sprintf("%s (%d relapse(s))", ws.Weekday, ws.CheckIns)
+	}
+	return fmt.Sprintf("%s (%s logged)", ws.Weekday, a.Habit.FormatAmount(ws.CheckIns))
+}
+
+var habitHeatmapCmd = &cobra.Command{
+	Use:     "heatmap [habit]",
+	Aliases: []string{"calendar", "cal"},
+	Short:   "Show a calendar heatmap of the past year",
+	Long: `Show a calendar heatmap of the past year, one column per week.
+Days are shaded by how much of the target was reached in their period. Without a habit, all habits are averaged.`,
+	Example: `mindloop habit heatmap
+	mindloop habit heatmap gym`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var heatmap models.Heatmap
+		var err error
+		if len(args) == 0 {
+			heatmap, err = habitService.OverallHeatmap(habitcore.HeatmapWeeks)
+		} else {
+			_, heatmap, err = habitService.Heatmap(args[0], habitcore.HeatmapWeeks)
+		}
+		if err != nil {
+			ac.Logger.Error().Err(err).Msg("Failed to build habit heatmap")
+			PrintErrorln("Failed to build habit heatmap:", err)
+			return
+		}
+		PrintHeatmap(heatmap)
+	},
+}
+
 // habit show subcommand
 var habitLogShowCmd = &cobra.Command{
 	Use:     "show",
@@ -277,7 +977,7 @@ var habitLogShowCmd = &cobra.Command{
 		PrintRocketln("'show me the logs'? Here you go Chief...")
 
 		intervalFilter := models.IntervalType("")
-		if !*daily && !*weekly { // nothing selected via flags
+		if !IntervalFlagSet() { // nothing selected via flags
 			PrintInfoln("No interval filter applied. Showing all habit logs.")
 			ac.Logger.Info().Msg("No interval filter applied. Showing all habit logs.")
 			intervalFilter = "" // no filter
@@ -285,7 +985,7 @@ var habitLogShowCmd = &cobra.Command{
 			intervalFilter = GetIntervalFromFlag()
 		}
 
-		habitLogs, err := habitService.ListHabitLogs(intervalFilter)
+		habitLogs, err := habitService.ListHabitLogs(habitcore.ListOptions{Interval: intervalFilter, Tag: *tagFilter})
 		if err != nil {
 			ac.Logger.Error().Err(err).Msg("Failed to retrieve habit logs")
 			PrintErrorln("Failed to retrieve habit logs:", err)
@@ -297,8 +997,30 @@ var habitLogShowCmd = &cobra.Command{
 			return
 		}
 
-		habitLogViews := models.ToHabitLogViews(habitLogs)
+		notes, err := habitService.LogNotes(habitLogs)
+		if err != nil {
+			ac.Logger.Error().Err(err).Msg("Failed to retrieve habit log notes")
+		}
+		habitLogViews := models.ToHabitLogViewsWithNotes(habitLogs, notes)
 		PrintTable(habitLogViews)
+
+		habits, err := habitService.ListHabits(habitcore.ListOptions{Interval: intervalFilter, Tag: *tagFilter})
+		if err != nil {
+			ac.Logger.Error().Err(err).Msg("Failed to retrieve habits")
+			return
+		}
+		for _, habit := range habits {
+			if !habit.IsAvoid() {
+				continue
+			}
+			streak, err := habitService.GetStreak(&habit)
+			if err != nil {
+				ac.Logger.Error().Err(err).Uint("habit_id", habit.ID).Msg("Failed to calculate habit streak")
+				continue
+			}
+			PrintInfof("%s: %d days since last relapse, clean streak %d (best %d)\n",
+				habit.Title, streak.DaysSinceRelapse, streak.Current, streak.Longest)
+		}
 	},
 }
 
@@ -310,14 +1032,78 @@ func init() {
 	habitCmd.AddCommand(habitUpdateCmd)
 	habitCmd.AddCommand(habitLogCmd)
 	habitCmd.AddCommand(habitUnLogCmd)
+	habitCmd.AddCommand(habitRelapseCmd)
 	habitCmd.AddCommand(habitListCmd)
+	habitCmd.AddCommand(habitHistoryCmd)
+	habitCmd.AddCommand(habitSearchCmd)
+	habitCmd.AddCommand(habitSyncCmd)
+	habitCmd.AddCommand(habitArchiveCmd)
+	habitCmd.AddCommand(habitUnarchiveCmd)
+	habitCmd.AddCommand(habitMoveCmd)
+	habitCmd.AddCommand(habitPinCmd)
+	habitCmd.AddCommand(habitUnpinCmd)
+	habitCmd.AddCommand(habitPauseCmd)
+	habitCmd.AddCommand(habitResumeCmd)
+	habitCmd.AddCommand(habitSkipCmd)
+	habitCmd.AddCommand(habitUnskipCmd)
+	habitCmd.AddCommand(habitRemindCmd)
+	habitCmd.AddCommand(habitHeatmapCmd)
+	habitCmd.AddCommand(habitInfoCmd)
 	habitLogCmd.AddCommand(habitLogShowCmd)
 
 	// flags
-	all = habitCmd.PersistentFlags().BoolP("all", "A", false, "Select all habits") // not using now
+	all = habitCmd.PersistentFlags().BoolP("all", "A", false, "Select all habits, including archived ones")
 	daily = habitCmd.PersistentFlags().BoolP("daily", "d", false, "Set habit as daily")
 	weekly = habitCmd.PersistentFlags().BoolP("weekly", "w", false, "Set habit as weekly")
+	monthly = habitCmd.PersistentFlags().BoolP("monthly", "m", false, "Set habit as monthly")
+	everyN = habitCmd.PersistentFlags().IntP("every", "e", 0, "Set habit to repeat every N days")
+	onWeekdays = habitCmd.PersistentFlags().StringP("on", "o", "", "Set habit to specific weekdays, e.g. mon,wed,fri")
 	interactive = habitCmd.PersistentFlags().BoolP("interactive", "i", false, "Interactive mode for adding habit")
+	unit = habitAddCmd.Flags().StringP("unit", "u", "", "Make the habit measurable in a unit, e.g. ml or pages. target_count is the target amount then")
+	logDate = habitLogCmd.Flags().StringP("date", "D", "", "Log the habit for a past date (YYYY-MM-DD)")
+	unlogDate = habitUnLogCmd.Flags().StringP("date", "D", "", "Unlog the habit for a past date (YYYY-MM-DD)")
+	avoid = habitAddCmd.Flags().Bool("avoid", false, "Add a habit to avoid, e.g. no sugar. It succeeds unless you log a relapse")
+	relapseDate = habitRelapseCmd.Flags().StringP("date", "D", "", "Log the relapse for a past date (YYYY-MM-DD)")
+	relapseNote = habitRelapseCmd.Flags().StringP("note", "n", "", "Add a note to the relapse")
+	logNote = habitLogCmd.Flags().StringP("note", "n", "", "Add a note to the log, e.g. \"5k in 28min\"")
+	unlogOne = habitUnLogCmd.Flags().Bool("one", false, "Undo only the most recent log instead of the whole period")
+	syncFile = habitSyncCmd.Flags().StringP("file", "f", habitcore.DefaultManifestPath, "Path to the habit manifest")
+	syncDryRun = habitSyncCmd.Flags().Bool("dry-run", false, "Show what would change without applying it")
+	pauseFrom = habitPauseCmd.Flags().String("from", "", "First paused day (YYYY-MM-DD), defaults to today")
+	pauseUntil = habitPauseCmd.Flags().String("until", "", "Last paused day (YYYY-MM-DD), pauses until resumed if empty")
+	pauseReason = habitPauseCmd.Flags().String("reason", "", "Why the habit is paused, e.g. vacation")
+	skipDate = habitSkipCmd.Flags().StringP("date", "D", "", "Skip the period of a past date (YYYY-MM-DD)")
+	skipReason = habitSkipCmd.Flags().String("reason", "", "Why the period is skipped, e.g. sick")
+	unskipDate = habitUnskipCmd.Flags().StringP("date", "D", "", "Unskip the period of a past date (YYYY-MM-DD)")
+	tags = habitAddCmd.Flags().StringP("tags", "t", "", "Comma separated tags to group the habit by, e.g. health,work")
+	remindAt = habitAddCmd.Flags().String("remind-at", "", "Time of day to be reminded if the habit is not done yet, e.g. 13:00 or \"1:00 PM\"")
+	window = habitAddCmd.Flags().String("window", "", "Time of day window to do the habit in, e.g. 06:00-09:00 or \"before 9am\". Logs outside it are flagged as late, relapses of habits to avoid outside it don't count")
+	remindOnce = habitRemindCmd.Flags().Bool("once", false, "Send the reminders due now and exit")
+	remindTick = habitRemindCmd.Flags().Duration("interval", time.Minute, "How often to check for due reminders")
+	remindVia = habitRemindCmd.Flags().String("notifier", "", "stdout, command or webhook, overrides the user config")
+	remindCmd = habitRemindCmd.Flags().String("command", "", "Shell command run by the command notifier")
+	remindURL = habitRemindCmd.Flags().String("webhook", "", "URL the webhook notifier posts to")
+	infoRecent = habitInfoCmd.Flags().IntP("recent", "r", 10, "Number of recent logs to show")
+	moveTo = habitMoveCmd.Flags().IntP("to", "t", 0, "Position to move the habit to, 1 being the top")
+	tagFilter = habitCmd.PersistentFlags().StringP("tag", "T", "", "Only select habits with this tag")
+}
+
+// ParseDateFlag parses a --date flag value in the configured timezone
+// Accepts YYYY-MM-DD, "today" and "yesterday". Empty means today (zero time)
+func ParseDateFlag(value string) (time.Time, error) {
+	cal := ac.Calendar()
+	switch strings.ToLower(value) {
+	case "", "today":
+		return time.Time{}, nil
+	case "yesterday":
+		return cal.AddDays(time.Now(), -1), nil
+	}
+	return time.ParseInLocation("2006-01-02", value, cal.Location)
+}
+
+// IntervalFlagSet reports whether any of the interval flags was set
+func IntervalFlagSet() bool {
+	return *daily || *weekly || *monthly || *everyN > 0 || *onWeekdays != ""
 }
 
 // GetIntervalFromFlag returns the interval type based on the flags set
@@ -327,8 +1113,14 @@ func GetIntervalFromFlag() models.IntervalType {
 		return models.Daily
 	} else if *weekly {
 		return models.Weekly
+	} else if *monthly {
+		return models.Monthly
+	} else if *everyN > 0 {
+		return models.EveryNDays
+	} else if *onWeekdays != "" {
+		return models.OnWeekdays
 	}
-	PrintInfoln("Defaulting to daily interval. Use -w or -d to set weekly or daily respectively.")
+	PrintInfoln("Defaulting to daily interval. Use -d, -w, -m, --every <n> or --on <weekdays> to set one.")
 	return models.Daily
 }
 
@@ -357,15 +1149,105 @@ func BuildHabitFromInteractiveMode(hb *models.Habit) *models.Habit {
 		hb.Description = desc
 	}
 
-	fmt.Print("Enter target count (default 1): ")
-	var targetCount int
-	fmt.Scanln(&targetCount)
-	if targetCount > 0 {
-		hb.TargetCount = targetCount
+	fmt.Printf("Is this a habit to build or to avoid? (build/avoid, current %s): ", hb.Kind)
+	inputReader = bufio.NewReader(os.Stdin)
+	input, _ = inputReader.ReadString('\n')
+	switch kind := models.HabitKind(strings.TrimSpace(input)); kind {
+	case models.KindBuild:
+		hb.Kind = kind
+		hb.TargetCount = max(hb.TargetCount, 1)
+	case models.KindAvoid:
+		hb.Kind = kind
+	}
+	if hb.IsAvoid() {
+		// nothing to count for habits to avoid
+		hb.TargetCount = 0
+		hb.Unit = ""
+	} else {
+		fmt.Printf("Enter unit for measurable habits, e.g. ml or pages (current %q, '-' to clear): ", hb.Unit)
+		inputReader = bufio.NewReader(os.Stdin)
+		input, _ = inputReader.ReadString('\n')
+		switch unit := strings.TrimSpace(input); unit {
+		case "":
+		case "-":
+			hb.Unit = ""
+		default:
+			hb.Unit = unit
+		}
+
+		if hb.IsMeasurable() {
+			fmt.Printf("Enter target amount in %s (current %d): ", hb.Unit, hb.TargetCount)
+		} else {
+			fmt.Printf("Enter target count (current %d): ", hb.TargetCount)
+		}
+		var targetCount int
+		fmt.Scanln(&targetCount)
+		if targetCount > 0 {
+			hb.TargetCount = targetCount
+		}
+	}
+
+	fmt.Printf("Enter tags, e.g. health,work (current %q, '-' to clear): ", hb.Tags)
+	inputReader = bufio.NewReader(os.Stdin)
+	input, _ = inputReader.ReadString('\n')
+	switch tagInput := strings.TrimSpace(input); tagInput {
+	case "":
+	case "-":
+		hb.Tags = ""
+	default:
+		hb.Tags = models.FormatTags(models.ParseTags(tagInput))
+	}
+
+	if !hb.IsAvoid() {
+		for {
+			fmt.Printf("Enter a time of day to be reminded at, e.g. 13:00 (current %q, '-' to clear): ", hb.RemindAt)
+			inputReader = bufio.NewReader(os.Stdin)
+			input, _ = inputReader.ReadString('\n')
+			value := strings.TrimSpace(input)
+			if value == "" {
+				break
+			}
+			if value == "-" {
+				hb.RemindAt = ""
+				break
+			}
+			at, err := models.ParseTimeOfDay(value)
+			if err == nil {
+				hb.RemindAt = at
+				break
+			}
+			PrintWarnln(err)
+		}
+	} else {
+		hb.RemindAt = ""
+	}
+
+	windowPrompt := "Enter a time window to do it in, e.g. 06:00-09:00 or \"before 9am\""
+	if hb.IsAvoid() {
+		windowPrompt = "Enter a time window relapses count in, e.g. \"after 10pm\""
+	}
+	for {
+		fmt.Printf("%s (current %q, '-' to clear): ", windowPrompt, hb.WindowLabel())
+		inputReader = bufio.NewReader(os.Stdin)
+		input, _ = inputReader.ReadString('\n')
+		value := strings.TrimSpace(input)
+		if value == "" {
+			break
+		}
+		if value == "-" {
+			hb.WindowStart, hb.WindowEnd = "", ""
+			break
+		}
+		start, end, err := models.ParseWindow(value)
+		if err == nil {
+			hb.WindowStart, hb.WindowEnd = start, end
+			break
+		}
+		PrintWarnln(err)
 	}
 
 	for {
-		fmt.Print("Select interval (daily/weekly, default daily): ")
+		fmt.Printf("Select interval (%s, default %s): ", strings.Join(models.AllIntervalTypes[:], "/"), hb.Interval)
 		var interval string
 		fmt.Scanln(&interval)
 		if interval != "" {
@@ -373,7 +1255,7 @@ func BuildHabitFromInteractiveMode(hb *models.Habit) *models.Habit {
 				ac.Logger.Error().
 					Interface("habit", hb).
 					Msg("Invalid interval type.")
-				PrintWarnln("Invalid interval type. Retry with 'daily' or 'weekly'.")
+				PrintWarnf("Invalid interval type. Retry with one of: %s.\n", strings.Join(models.AllIntervalTypes[:], ", "))
 				continue
 			}
 			hb.Interval = models.IntervalType(interval)
@@ -382,5 +1264,106 @@ func BuildHabitFromInteractiveMode(hb *models.Habit) *models.Habit {
 		break
 	}
 
+	switch hb.Interval {
+	case models.EveryNDays:
+		for {
+			fmt.Printf("Repeat every how many days? (current %d): ", hb.EveryN)
+			var n int
+			fmt.Scanln(&n)
+			if n > 0 {
+				hb.EveryN = n
+			}
+			if hb.EveryN > 0 {
+				break
+			}
+			PrintWarnln("Please enter a number of days greater than 0.")
+		}
+	case models.OnWeekdays:
+		for {
+			fmt.Printf("On which weekdays? e.g. mon,wed,fri (current %q): ", hb.Weekdays)
+			var days string
+			fmt.Scanln(&days)
+			if days == "" {
+				days = hb.Weekdays
+			}
+			parsed, err := models.ParseWeekdays(days)
+			if err == nil && len(parsed) > 0 {
+				hb.Weekdays = models.FormatWeekdays(parsed)
+				break
+			}
+			PrintWarnln("Please enter at least one valid weekday, e.g. mon,wed,fri.")
+		}
+	}
+
 	return hb
 }
+
+// heatmapShades are the 256 color codes of the heatmap levels, gray for
+// nothing done up to bright green for the target reached
+var heatmapShades = [models.HeatmapLevels]int{238, 22, 28, 34, 46}
+
+// heatmapBlocks draw the levels when colors are off
+var heatmapBlocks = [models.HeatmapLevels]string{"·", "░", "▒", "▓", "█"}
+
+// PrintHeatmap prints the heatmap with a row per weekday and a column per
+// week, GitHub style. Colors are left out if NO_COLOR is set or stdout is
+// not a terminal.
+func PrintHeatmap(hm models.Heatmap) {
+	weeks := hm.Weeks()
+	if len(weeks) == 0 {
+		return
+	}
+	color := os.Getenv("NO_COLOR") == ""
+	if fi, err := os.Stdout.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		color = false
+	}
+	cell := func(d models.HeatmapDay) string {
+		level := d.Level()
+		switch {
+		case level < 0:
+			return " "
+		case color:
+			return fmt.Sprintf("\033[38;5;%dm█\033[0m", heatmapShades[level])
+		}
+		return heatmapBlocks[level]
+	}
+
+	months := []rune(strings.Repeat(" ", len(weeks)+3))
+	for _, i := range hm.MonthStarts() {
+		copy(months[i:], []rune(weeks[i][0].Date.Format("Jan")))
+	}
+
+	fmt.Printf("%s, past %d weeks\n", hm.Title, len(weeks))
+	fmt.Printf("    %s\n", strings.TrimRight(string(months), " "))
+	for row := range 7 {
+		label := "   "
+		if row%2 == 0 && row < min(6, len(weeks[0])) {
+			label = weeks[0][row].Date.Format("Mon")
+		}
+		var line strings.Builder
+		for _, week := range weeks {
+			if row < len(week) {
+				line.WriteString(cell(week[row]))
+			}
+		}
+		fmt.Printf("%s %s\n", label, line.String())
+	}
+
+	legend := make([]string, models.HeatmapLevels)
+	due, done := 0, 0
+	for level := range legend {
+		legend[level] = cell(models.HeatmapDay{Due: true, Ratio: float64(level) / float64(models.HeatmapLevels-1)})
+	}
+	for _, d := range hm.Days {
+		if d.Due {
+			due++
+			if d.Ratio >= 1 {
+				done++
+			}
+		}
+	}
+	fmt.Printf("    Less %s More\n", strings.Join(legend, ""))
+	if due > 0 {
+		fmt.Printf("    Target reached on %d of %d due days (%.0f%%)\n", done, due, float64(done)*100/float64(due))
+	}
+}
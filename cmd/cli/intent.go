@@ -63,7 +63,7 @@ var intentListCmd = &cobra.Command{
 
 		views := []models.IntentView{}
 		for _, i := range intents {
-			views = append(views, models.ToIntentView(i))
+			views = append(views, models.ToIntentView(i, ac.FocusIdleCutoff()))
 		}
 		utils.PrintTable(views)
 		ac.Logger.Info().Msgf("Listed %d intents successfully.", len(intents))
@@ -91,7 +91,7 @@ var intentCurrentCmd = &cobra.Command{
 
 		views := []models.IntentView{}
 		for _, i := range intents {
-			views = append(views, models.ToIntentView(i))
+			views = append(views, models.ToIntentView(i, ac.FocusIdleCutoff()))
 		}
 		PrintTable(views)
 		ac.Logger.Info().Msgf("Listed %d active intents successfully.", len(intents))
@@ -100,12 +100,13 @@ var intentCurrentCmd = &cobra.Command{
 
 // end intent subcommand
 var intentEndCmd = &cobra.Command{
-	Use:     "end",
-	Short:   "End intent",
-	Example: `mindloop intent end 10`,
+	Use:   "end",
+	Short: "End intent",
+	Example: `mindloop intent end 10
+	mindloop intent end "get this"`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) < 1 {
-			PrintWarnln("Please provide the intent ID to end.")
+			PrintWarnln("Please provide the intent ID or name to end.")
 			ac.Logger.Warn().Msg("No intent ID provided for ending intent.")
 			return
 		}
@@ -118,7 +119,7 @@ var intentEndCmd = &cobra.Command{
 		}
 
 		ac.Logger.Info().Msgf("Intent '%s' ended successfully!", intent.Name)
-		intentView := models.ToIntentView(*intent)
+		intentView := models.ToIntentView(*intent, ac.FocusIdleCutoff())
 		PrintTable([]models.IntentView{intentView})
 	},
 }
@@ -91,10 +91,11 @@ var journalListCmd = &cobra.Command{
 }
 
 var journalViewCmd = &cobra.Command{
-	Use:     "view",
-	Short:   "View a specific journal entry",
-	Example: `mindloop journal view <id>`,
-	Args:    cobra.ExactArgs(1),
+	Use:   "view",
+	Short: "View a specific journal entry",
+	Example: `mindloop journal view <id>
+	mindloop journal view "monday thoughts"`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		id := args[0]
 		entry, err := journalService.GetEntry(id)
@@ -136,7 +137,7 @@ var journalDeleteCmd = &cobra.Command{
 		}
 
 		PrintRocketf("Deleting journal entry '%s'\n", entry.Title)
-		err = journalService.DeleteEntry(id)
+		err = journalService.DeleteEntry(fmt.Sprint(entry.ID))
 		if err != nil {
 			PrintErrorln("Failed to delete journal entry:", err)
 			ac.Logger.Error().Msgf("Failed to delete journal entry with ID %s: %v", id, err)
@@ -3,11 +3,13 @@ package cli
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"gorm.io/gorm"
 
 	"github.com/snehmatic/mindloop/db"
 	"github.com/snehmatic/mindloop/internal/config"
+	"github.com/snehmatic/mindloop/internal/core/focus"
 	"github.com/snehmatic/mindloop/internal/utils"
 	"github.com/spf13/cobra"
 )
@@ -66,4 +68,19 @@ func initConfig() {
 		os.Exit(1)
 	}
 	gdb = db
+	endOverdueSessions()
+}
+
+// endOverdueSessions ends the focus sessions that ran past their planned
+// duration since mindloop last ran, and says so
+func endOverdueSessions() {
+	ended, err := focus.NewService(gdb).EndOverdue(time.Now())
+	if err != nil {
+		ac.Logger.Error().Err(err).Msg("Failed to end overdue focus sessions")
+	}
+	for _, session := range ended {
+		ac.Logger.Info().Msgf("Focus session '%s' auto-ended at its planned time", session.Title)
+		utils.PrintInfof("Focus session '%s' reached its planned %s and was ended at %s.\n",
+			session.Title, utils.FormatMinutes(float64(session.PlannedMinutes)), session.EndTime.Format("15:04"))
+	}
 }
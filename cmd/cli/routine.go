@@ -0,0 +1,301 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/snehmatic/mindloop/internal/core/focus"
+	habitcore "github.com/snehmatic/mindloop/internal/core/habit"
+	"github.com/snehmatic/mindloop/internal/core/routine"
+	. "github.com/snehmatic/mindloop/internal/utils"
+	"github.com/snehmatic/mindloop/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	routineService     *routine.Service
+	routineSteps       *[]string
+	routineDescription *string
+)
+
+var routineCmd = &cobra.Command{
+	Use:   "routine",
+	Short: "Manage your routines",
+	Long: `Routines are ordered lists of habits and focus blocks, e.g. a morning routine.
+Running one walks you through its steps and logs each habit as you go.`,
+	Example: `mindloop routine run morning`,
+	Args:    cobra.NoArgs,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		routineService = routine.NewService(gdb)
+	},
+}
+
+var routineAddCmd = &cobra.Command{
+	Use:     "add",
+	Aliases: []string{"new", "create"},
+	Short:   "Add a routine",
+	Long: `Add a routine made of the given steps, in order. A step is a habit (ID, slug or name)
+or a focus block: "focus", "focus:25m" or "focus:Deep work:25m".`,
+	Example: `mindloop routine add morning --steps meditate,stretch,focus:Plan:15m
+	mindloop routine add "Wind down" -s "journal,read" -d "Before bed"`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		steps, err := routineService.ParseSteps(*routineSteps)
+		if err != nil {
+			PrintErrorln("Invalid steps:", err)
+			return
+		}
+
+		r := &models.Routine{Name: args[0], Description: *routineDescription, Steps: steps}
+		if err := routineService.CreateRoutine(r); err != nil {
+			if errors.Is(err, routine.ErrNoSteps) {
+				PrintWarnln("Please provide the steps of the routine, e.g. --steps meditate,stretch,focus:25m")
+				return
+			}
+			ac.Logger.Error().Err(err).Msg("Failed to create routine")
+			PrintErrorln("Failed to create routine:", err)
+			return
+		}
+
+		ac.Logger.Info().Interface("routine", r).Msg("Routine created successfully")
+		PrintSuccessf("Routine '%s' created with %d step(s), run it with 'mindloop routine run %s'.\n", r.Name, len(r.Steps), Slugify(r.Name))
+	},
+}
+
+var routineListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List your routines",
+	Example: `mindloop routine list`,
+	Run: func(cmd *cobra.Command, args []string) {
+		routines, err := routineService.ListRoutines()
+		if err != nil {
+			ac.Logger.Error().Err(err).Msg("Failed to list routines")
+			PrintErrorln("Failed to list routines:", err)
+			return
+		}
+		if len(routines) == 0 {
+			PrintInfoln("No routines found... Try adding one with 'mindloop routine add <name> --steps <habits>'")
+			return
+		}
+
+		views := make([]models.RoutineView, len(routines))
+		for i, r := range routines {
+			views[i] = models.ToRoutineView(r)
+		}
+		PrintTable(views)
+	},
+}
+
+var routineShowCmd = &cobra.Command{
+	Use:     "show",
+	Aliases: []string{"info"},
+	Short:   "Show the steps of a routine and where they stand today",
+	Example: `mindloop routine show morning`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		r, err := routineService.GetRoutine(args[0])
+		if err != nil {
+			PrintErrorln("Routine not found:", err)
+			return
+		}
+
+		fmt.Printf("🧭 %s\n", r.Name)
+		if r.Description != "" {
+			fmt.Println(r.Description)
+		}
+		now := time.Now()
+		for _, step := range r.Steps {
+			status := ""
+			switch done, err := routineService.StepDone(step, now); {
+			case !routineService.StepDue(step, now):
+				status = " (not due today)"
+			case err == nil && done:
+				status = " ✓"
+			}
+			fmt.Printf("%d. %s%s\n", step.Position, step.Label(), status)
+		}
+	},
+}
+
+var routineDeleteCmd = &cobra.Command{
+	Use:     "delete",
+	Aliases: []string{"rm", "remove", "del"},
+	Short:   "Delete a routine",
+	Long:    `Delete a routine. Its habits and past runs are kept.`,
+	Example: `mindloop routine delete morning`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		r, err := routineService.GetRoutine(args[0])
+		if err != nil {
+			PrintErrorln("Routine not found:", err)
+			return
+		}
+		if err := routineService.DeleteRoutine(strconv.FormatUint(uint64(r.ID), 10)); err != nil {
+			ac.Logger.Error().Err(err).Msg("Failed to delete routine")
+			PrintErrorln("Failed to delete routine:", err)
+			return
+		}
+		PrintSuccessf("Routine '%s' deleted successfully.\n", r.Name)
+	},
+}
+
+var routineRunCmd = &cobra.Command{
+	Use:     "run",
+	Aliases: []string{"start", "go"},
+	Short:   "Walk through a routine",
+	Long: `Walk through the steps of a routine one by one. Habits are logged as you confirm them,
+focus blocks start a focus session that ends when you press Enter. Steps not due today
+and habits already done are skipped.`,
+	Example: `mindloop routine run morning`,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		r, err := routineService.GetRoutine(args[0])
+		if err != nil {
+			PrintErrorln("Routine not found:", err)
+			return
+		}
+
+		now := time.Now()
+		var due []models.RoutineStep
+		for _, step := range r.Steps {
+			if routineService.StepDue(step, now) {
+				due = append(due, step)
+			} else {
+				PrintInfof("Leaving out '%s', it's not due today.\n", step.Label())
+			}
+		}
+		if len(due) == 0 {
+			PrintInfof("Nothing in '%s' is due today, enjoy the day off!\n", r.Name)
+			return
+		}
+
+		PrintRocketf("Starting '%s', %d step(s) to go. Answer q to stop at any step.\n", r.Name, len(due))
+		reader := bufio.NewReader(os.Stdin)
+		done := 0
+	steps:
+		for i, step := range due {
+			fmt.Printf("\n[%d/%d] %s\n", i+1, len(due), step.Label())
+			if ok, err := routineService.StepDone(step, now); err == nil && ok {
+				PrintSuccessln("Already done, moving on.")
+				done++
+				continue
+			}
+
+			switch ask(reader, "Do it now? [Y/n/q]: ") {
+			case "n", "no":
+				PrintInfoln("Skipped.")
+				continue
+			case "q", "quit":
+				break steps
+			}
+
+			if step.IsFocus() {
+				if runFocusBlock(reader, step) {
+					done++
+				}
+				continue
+			}
+			if runHabitStep(reader, step) {
+				done++
+			}
+		}
+
+		run, err := routineService.SaveRun(r, done, len(due))
+		if err != nil {
+			ac.Logger.Error().Err(err).Msg("Failed to save routine run")
+			PrintErrorln("Failed to save routine run:", err)
+			return
+		}
+		ac.Logger.Info().Interface("run", run).Msg("Routine run saved")
+		fmt.Println()
+		if run.Completed() {
+			PrintRocketf("Routine '%s' completed, all %d step(s) done!\n", r.Name, run.StepsTotal)
+			return
+		}
+		PrintSuccessf("Routine '%s' ended with %d/%d step(s) done.\n", r.Name, run.StepsDone, run.StepsTotal)
+	},
+}
+
+// ask prints the prompt and returns the trimmed, lower cased answer
+func ask(reader *bufio.Reader, prompt string) string {
+	fmt.Print(prompt)
+	input, _ := reader.ReadString('\n')
+	return strings.ToLower(strings.TrimSpace(input))
+}
+
+// runHabitStep logs the habit of the step, asking for the amount of
+// measurable habits. It reports whether the step got done.
+func runHabitStep(reader *bufio.Reader, step models.RoutineStep) bool {
+	amount := 0
+	if step.Habit.IsMeasurable() {
+		for {
+			input := ask(reader, fmt.Sprintf("How much in %s? (default %d): ", step.Habit.Unit, step.Habit.TargetCount))
+			if input == "" {
+				amount = step.Habit.TargetCount
+				break
+			}
+			if n, err := strconv.Atoi(input); err == nil && n > 0 {
+				amount = n
+				break
+			}
+			PrintWarnln("Please enter a positive whole number.")
+		}
+	}
+
+	habit, log, err := routineService.LogStep(step, habitcore.LogOptions{Amount: amount})
+	if err != nil {
+		if errors.Is(err, habitcore.ErrAlreadyCompleted) {
+			PrintSuccessln("Already done, moving on.")
+			return true
+		}
+		ac.Logger.Error().Err(err).Msg("Failed to log routine step")
+		PrintErrorln("Failed to log habit:", err)
+		return false
+	}
+	PrintSuccessf("Logged '%s' (%d/%s).\n", habit.Title, log.ActualCount, habit.FormatAmount(log.TargetCount))
+	return true
+}
+
+// runFocusBlock runs a focus session for the step until Enter is pressed.
+// It reports whether the block got done.
+func runFocusBlock(reader *bufio.Reader, step models.RoutineStep) bool {
+	session, err := routineService.Focus.StartSession(step.FocusTitle, focus.StartOptions{})
+	if err != nil {
+		ac.Logger.Error().Err(err).Msg("Failed to start routine focus block")
+		PrintErrorln("Error starting focus session:", err)
+		return false
+	}
+	if step.FocusMinutes > 0 {
+		PrintLoadingf("Focus session '%s' started, aim for %d minutes. Press Enter when you're done.", session.Title, step.FocusMinutes)
+	} else {
+		PrintLoadingf("Focus session '%s' started. Press Enter when you're done.", session.Title)
+	}
+	reader.ReadString('\n')
+
+	session, err = routineService.Focus.EndSession(int(session.ID))
+	if err != nil {
+		ac.Logger.Error().Err(err).Msg("Failed to end routine focus block")
+		PrintErrorln("Error ending focus session:", err)
+		return false
+	}
+	PrintSuccessf("Focus session '%s' ended after %.0f minute(s).\n", session.Title, session.Duration)
+	return true
+}
+
+func init() {
+	rootCmd.AddCommand(routineCmd)
+	routineCmd.AddCommand(routineAddCmd)
+	routineCmd.AddCommand(routineListCmd)
+	routineCmd.AddCommand(routineShowCmd)
+	routineCmd.AddCommand(routineDeleteCmd)
+	routineCmd.AddCommand(routineRunCmd)
+
+	routineSteps = routineAddCmd.Flags().StringSliceP("steps", "s", nil, "Comma separated steps: habits, or focus blocks like focus:Deep work:25m")
+	routineDescription = routineAddCmd.Flags().StringP("description", "d", "", "Description of the routine")
+}
@@ -57,22 +57,22 @@ func init() {
 	day = summaryCmd.Flags().BoolP("day", "d", false, "Show summary for today")
 }
 
+// GetTimeRangeFromFlags returns the summary range for the flags set, the current
+// day, week and month follow the configured timezone and week start
 func GetTimeRangeFromFlags() (time.Time, time.Time) {
-	now := time.Now()
+	cal := ac.Calendar()
+	now := time.Now().In(cal.Location)
 	if *year {
-		return time.Date(now.Year()-1, now.Month(), now.Day(), 0, 0, 0, 0, now.Location()), now
+		return cal.StartOfDay(now.AddDate(-1, 0, 0)), now
 	} else if *month {
-		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()), now.AddDate(0, 1, -now.Day())
+		return cal.Month(now).Start, now
 	} else if *week {
-		end := time.Now()
-		start := end.AddDate(0, 0, -7)
-		start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
-		return start, end
+		return cal.Week(now).Start, now
 	} else if *day {
-		return now.Add(-24 * time.Hour), now
+		return cal.Day(now).Start, now
 	}
 	// default range "day"
-	return now.Add(-24 * time.Hour), now
+	return cal.Day(now).Start, now
 }
 
 func PrintSummary(report models.SummaryReport) {
@@ -82,7 +82,7 @@ func PrintSummary(report models.SummaryReport) {
 	// Intent block
 	fmt.Println("\n🎯 Intent Stats")
 	for _, i := range report.Intents {
-		fmt.Printf("- %s: %s\n", i.IntentName, i.Status)
+		fmt.Printf("- %s: %s, %s focused\n", i.IntentName, i.Status, i.FocusTime)
 	}
 
 	// Focus block
@@ -90,10 +90,64 @@ func PrintSummary(report models.SummaryReport) {
 	fmt.Printf("- Total Sessions: %d\n", report.Focus.TotalSessions)
 	fmt.Printf("- Total Duration: %s\n", report.Focus.TotalDuration)
 	fmt.Printf("- Longest Session: %s\n", report.Focus.LongestSession)
+	if report.Focus.Pomodoros > 0 {
+		fmt.Printf("- Pomodoros: %d 🍅\n", report.Focus.Pomodoros)
+	}
+	if report.Focus.StaleSessions > 0 {
+		fmt.Printf("- Stale Sessions: %d, counted up to the idle cutoff only, see 'mindloop focus fix'\n", report.Focus.StaleSessions)
+	}
+	if report.Focus.PlannedSessions > 0 {
+		fmt.Printf("- Planned Sessions: %d, %s over and %s under plan\n", report.Focus.PlannedSessions, report.Focus.Overrun, report.Focus.Underrun)
+	}
 
 	// Habit block
 	fmt.Println("\n📓 Habit Stats")
 	for _, h := range report.Habits {
-		fmt.Printf("- %s: %.0f%% (%d/%d)\n", h.HabitName, h.CompletionRate, h.LogsCompleted, h.LogsTracked)
+		name := h.HabitName
+		if h.Archived {
+			name += " (archived)"
+		}
+		if h.Avoid {
+			fmt.Printf("- %s: %.0f%% clean (%d/%d) 🔥 streak %d, best %d, %d days since last relapse, %d relapse(s)\n",
+				name, h.CompletionRate, h.LogsCompleted, h.PeriodsDue,
+				h.CurrentStreak, h.LongestStreak, h.DaysSinceRelapse, h.TotalAmount)
+			if h.Skipped > 0 {
+				fmt.Printf("  %d period(s) skipped\n", h.Skipped)
+			}
+			continue
+		}
+		fmt.Printf("- %s: %.0f%% (%d/%d) 🔥 streak %d, best %d, last done %s\n",
+			name, h.CompletionRate, h.LogsCompleted, max(h.PeriodsDue, h.LogsCompleted),
+			h.CurrentStreak, h.LongestStreak, h.LastCompleted)
+		if h.Window != "" {
+			fmt.Printf("  ⏰ %s: %.0f%% on time (%d), %.0f%% late (%d)\n",
+				h.Window, h.OnTimeRate, h.CompletedOnTime, h.LateRate, h.CompletedLate)
+		}
+		if h.Skipped > 0 {
+			fmt.Printf("  %d period(s) skipped\n", h.Skipped)
+		}
+		if h.Unit != "" {
+			fmt.Printf("  %s logged in total\n", models.FormatAmount(h.TotalAmount, h.Unit))
+		}
+	}
+
+	if len(report.Tags) > 0 {
+		fmt.Println("\n🏷️  By Tag")
+		for _, t := range report.Tags {
+			tag := "#" + t.Tag
+			if t.Tag == "" {
+				tag = "untagged"
+			}
+			fmt.Printf("- %s: %.0f%% (%d/%d) across %d habit(s)\n",
+				tag, t.CompletionRate, t.LogsCompleted, t.PeriodsDue, t.Habits)
+		}
+	}
+
+	if len(report.Routines) > 0 {
+		fmt.Println("\n🧭 Routines")
+		for _, r := range report.Routines {
+			fmt.Printf("- %s: %.0f%% of runs completed (%d/%d), %d/%d steps done\n",
+				r.Name, r.CompletionRate, r.Completed, r.Runs, r.StepsDone, r.StepsTotal)
+		}
 	}
 }
@@ -19,6 +19,7 @@ import (
 	"github.com/snehmatic/mindloop/internal/core/intent"
 	"github.com/snehmatic/mindloop/internal/core/journal"
 	"github.com/snehmatic/mindloop/internal/core/summary"
+	"github.com/snehmatic/mindloop/internal/notify"
 )
 
 const (
@@ -46,11 +47,20 @@ func CreateRouter(mlh *v1.MindloopHandler) (*mux.Router, error) {
 	r.HandleFunc("/habits/log", mlh.HandleHabitLog).Methods("POST")
 	r.HandleFunc("/habits/unlog", mlh.HandleHabitUnlog).Methods("POST")
 	r.HandleFunc("/habits/delete", mlh.HandleHabitDelete).Methods("POST")
+	r.HandleFunc("/habits/archive", mlh.HandleHabitArchive).Methods("POST")
+	r.HandleFunc("/habits/move", mlh.HandleHabitMove).Methods("POST")
+	r.HandleFunc("/habits/pin", mlh.HandleHabitPin).Methods("POST")
+	r.HandleFunc("/habits/pause", mlh.HandleHabitPause).Methods("POST")
+	r.HandleFunc("/habits/resume", mlh.HandleHabitResume).Methods("POST")
+	r.HandleFunc("/habits/skip", mlh.HandleHabitSkip).Methods("POST")
+	r.HandleFunc("/habits/{id}", mlh.HandleHabitDetail).Methods("GET")
 
 	// Focus Routes
 	r.HandleFunc("/focus", mlh.HandleFocus).Methods("GET")
 	r.HandleFunc("/focus/start", mlh.HandleFocusStart).Methods("POST")
 	r.HandleFunc("/focus/stop", mlh.HandleFocusStop).Methods("POST")
+	r.HandleFunc("/focus/pause", mlh.HandleFocusPause).Methods("POST")
+	r.HandleFunc("/focus/fix", mlh.HandleFocusFix).Methods("POST")
 
 	// Intent Routes
 	r.HandleFunc("/intent", mlh.HandleIntent).Methods("GET")
@@ -126,5 +136,22 @@ func main() {
 		summaryService,
 	)
 
+	// Planned focus sessions end by themselves once they reach their time
+	go focusService.RunAutoEnd(context.Background(), time.Minute, func(err error) {
+		log.Error().Err(err).Msg("Failed to end overdue focus sessions")
+	})
+
+	// Reminders are only sent by the server when a notifier is configured,
+	// otherwise 'mindloop habit remind' takes care of them
+	if appConfig.Reminders.Notifier != "" {
+		notifier, err := notify.FromConfig(appConfig.Reminders)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid reminder notifier")
+		}
+		go habit.NewScheduler(habitService, notifier).Run(context.Background(), time.Minute, func(err error) {
+			log.Error().Err(err).Msg("Failed to send reminders")
+		})
+	}
+
 	ServeMindloop(mlh)
 }
@@ -2,9 +2,11 @@ package db
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/snehmatic/mindloop/internal/config"
 	"github.com/snehmatic/mindloop/internal/log"
+	"github.com/snehmatic/mindloop/internal/period"
 	"github.com/snehmatic/mindloop/internal/utils"
 	"github.com/snehmatic/mindloop/models"
 	"gorm.io/driver/postgres"
@@ -84,13 +86,76 @@ func MigrateDB(db *gorm.DB) error {
 	err := db.AutoMigrate(
 		&models.Intent{},
 		&models.FocusSession{},
+		&models.FocusSegment{},
 		&models.Habit{},
 		&models.HabitLog{},
+		&models.HabitPause{},
+		&models.HabitEvent{},
+		&models.HabitRevision{},
+		&models.Routine{},
+		&models.RoutineStep{},
+		&models.RoutineRun{},
 		&models.JournalEntry{},
+		&models.Migration{},
 	)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to migrate DB")
 		return err
 	}
+	err = runOnce(db, "legacy_habit_log_periods", func(tx *gorm.DB) error {
+		return migrateLegacyLogs(tx, config.GetConfig().Calendar())
+	})
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to migrate legacy habit logs")
+		return err
+	}
+	return nil
+}
+
+// runOnce applies a one-time data migration, unless a Migration row named
+// after it says it already was. The migration and its row are written in
+// one transaction.
+func runOnce(db *gorm.DB, name string, migrate func(tx *gorm.DB) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var applied int64
+		if err := tx.Model(&models.Migration{}).Where("Name = ?", name).Count(&applied).Error; err != nil {
+			return err
+		}
+		if applied > 0 {
+			return nil
+		}
+		if err := migrate(tx); err != nil {
+			return err
+		}
+		return tx.Create(&models.Migration{Name: name, AppliedAt: time.Now()}).Error
+	})
+}
+
+// migrateLegacyLogs moves habit logs written before periods were stored to
+// the period keys used since. Those logs have no StartedAt and their EndedAt
+// is midnight UTC: the day itself for daily habits, and the Saturday ending
+// a Sunday to Saturday week for weekly ones, the only intervals back then.
+// Days keep their date in the calendar's timezone, weeks become the calendar
+// week containing that Saturday.
+func migrateLegacyLogs(db *gorm.DB, cal period.Calendar) error {
+	var logs []models.HabitLog
+	if err := db.Where("StartedAt IS NULL OR StartedAt = ?", time.Time{}).Find(&logs).Error; err != nil {
+		return err
+	}
+	for _, habitLog := range logs {
+		utc := habitLog.EndedAt.UTC()
+		day := time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, cal.Location)
+		p := cal.Day(day)
+		if habitLog.Interval == models.Weekly {
+			p = cal.Week(day)
+		}
+		err := db.Model(&habitLog).Updates(map[string]any{"StartedAt": p.Start, "EndedAt": p.End}).Error
+		if err != nil {
+			return err
+		}
+	}
+	if len(logs) > 0 {
+		logger.Info().Msgf("Migrated %d habit log(s) to calendar periods", len(logs))
+	}
 	return nil
 }
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/snehmatic/mindloop/internal/period"
+	"github.com/snehmatic/mindloop/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func TestMigrateLegacyLogs(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true, NoLowerCase: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect to test db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.HabitLog{}, &models.Migration{}); err != nil {
+		t.Fatalf("Failed to migrate test db: %v", err)
+	}
+
+	// Keys as written before periods were stored: midnight UTC, weeks
+	// ending on Saturday
+	utc := func(s string) time.Time {
+		t, _ := time.Parse("2006-01-02", s)
+		return t
+	}
+	daily := models.HabitLog{HabitID: 1, Title: "Read", Interval: models.Daily, TargetCount: 1, ActualCount: 1, EndedAt: utc("2026-10-07")}
+	weekly := models.HabitLog{HabitID: 2, Title: "Gym", Interval: models.Weekly, TargetCount: 3, ActualCount: 2, EndedAt: utc("2026-10-10")}
+	db.Create(&daily)
+	db.Create(&weekly)
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	cal := period.New(berlin, time.Monday)
+	migrate := func(tx *gorm.DB) error { return migrateLegacyLogs(tx, cal) }
+	if err := runOnce(db, "legacy_habit_log_periods", migrate); err != nil {
+		t.Fatal(err)
+	}
+
+	db.First(&daily, daily.ID)
+	db.First(&weekly, weekly.ID)
+	wantDay := time.Date(2026, 10, 7, 0, 0, 0, 0, berlin)
+	if !daily.StartedAt.Equal(wantDay) || !daily.EndedAt.Equal(wantDay) {
+		t.Errorf("expected the daily log on 2026-10-07 in Berlin, got %v to %v", daily.StartedAt, daily.EndedAt)
+	}
+	week := cal.Week(wantDay)
+	if !weekly.StartedAt.Equal(week.Start) || !weekly.EndedAt.Equal(time.Date(2026, 10, 11, 0, 0, 0, 0, berlin)) {
+		t.Errorf("expected the weekly log in the week ending Sunday 2026-10-11, got %v to %v", weekly.StartedAt, weekly.EndedAt)
+	}
+
+	// Once applied, the migration leaves later logs alone
+	later := models.HabitLog{HabitID: 1, Title: "Read", Interval: models.Daily, TargetCount: 1, ActualCount: 1, EndedAt: utc("2026-10-08")}
+	db.Create(&later)
+	if err := runOnce(db, "legacy_habit_log_periods", migrate); err != nil {
+		t.Fatal(err)
+	}
+	db.First(&later, later.ID)
+	if !later.StartedAt.IsZero() || !later.EndedAt.Equal(utc("2026-10-08")) {
+		t.Errorf("expected the migration not to run twice, got %v to %v", later.StartedAt, later.EndedAt)
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog"
 	"github.com/snehmatic/mindloop/internal/log"
+	"github.com/snehmatic/mindloop/internal/period"
 	"github.com/snehmatic/mindloop/internal/utils"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
@@ -29,11 +31,32 @@ var (
 
 // mindloop Application global configuration
 type Config struct {
-	Mode     MindloopMode
-	Port     string
-	Name     string
-	DBConfig DBConfig
-	Logger   zerolog.Logger
+	Mode      MindloopMode
+	Port      string
+	Name      string
+	DBConfig  DBConfig
+	Logger    zerolog.Logger
+	Location  *time.Location // timezone days, weeks and months are computed in
+	WeekStart time.Weekday
+	Reminders ReminderConfig
+	// IdleCutoff is how long a focus session can run before it is flagged
+	// as stale, likely left running by mistake
+	IdleCutoff time.Duration
+}
+
+// DefaultIdleCutoff is the idle cutoff when the user config sets none
+const DefaultIdleCutoff = 12 * time.Hour
+
+// FocusConfig holds focus session preferences
+type FocusConfig struct {
+	IdleCutoff string `yaml:"idle_cutoff,omitempty"` // e.g. "8h", defaults to DefaultIdleCutoff
+}
+
+// ReminderConfig picks how habit reminders are delivered
+type ReminderConfig struct {
+	Notifier   string `yaml:"notifier,omitempty"`    // stdout (default), command or webhook
+	Command    string `yaml:"command,omitempty"`     // shell command for the command notifier
+	WebhookURL string `yaml:"webhook_url,omitempty"` // URL the webhook notifier posts to
 }
 
 type DBConfig struct {
@@ -51,11 +74,30 @@ func InitConfig(name, mode, port string) {
 	once.Do(func() { // singleton
 
 		config = &Config{
-			Name:     name,
-			Port:     port,
-			Mode:     MindloopMode(mode),
-			DBConfig: DBConfig{},
-			Logger:   log.Get(),
+			Name:       name,
+			Port:       port,
+			Mode:       MindloopMode(mode),
+			DBConfig:   DBConfig{},
+			Logger:     log.Get(),
+			Location:   time.Local,
+			WeekStart:  time.Monday,
+			IdleCutoff: DefaultIdleCutoff,
+		}
+
+		// Calendar preferences live in the user config, if there is one
+		if utils.FileExists(UserConfigPath) {
+			uc := UserConfig{}
+			if err := uc.ReadFromYAML(); err != nil {
+				config.Logger.Warn().Err(err).Msg("Failed to read user config, using default calendar preferences")
+			} else {
+				if err := config.applyCalendarPreferences(uc); err != nil {
+					config.Logger.Warn().Err(err).Msg("Invalid calendar preferences in user config, using defaults")
+				}
+				config.Reminders = uc.Reminders
+				if err := config.applyFocusPreferences(uc); err != nil {
+					config.Logger.Warn().Err(err).Msg("Invalid focus preferences in user config, using defaults")
+				}
+			}
 		}
 
 		if mode == "api" {
@@ -81,10 +123,62 @@ func GetConfig() *Config {
 	return config
 }
 
+// Calendar returns the period calendar for the configured timezone and week start.
+// Safe to call before InitConfig, falls back to period.Default() then.
+func (c *Config) Calendar() period.Calendar {
+	if c == nil {
+		return period.Default()
+	}
+	return period.New(c.Location, c.WeekStart)
+}
+
+func (c *Config) applyCalendarPreferences(uc UserConfig) error {
+	if uc.Timezone != "" {
+		loc, err := time.LoadLocation(uc.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", uc.Timezone, err)
+		}
+		c.Location = loc
+	}
+	if uc.WeekStart != "" {
+		weekStart, err := period.ParseWeekday(uc.WeekStart)
+		if err != nil {
+			return err
+		}
+		c.WeekStart = weekStart
+	}
+	return nil
+}
+
+func (c *Config) applyFocusPreferences(uc UserConfig) error {
+	if uc.Focus.IdleCutoff == "" {
+		return nil
+	}
+	cutoff, err := time.ParseDuration(uc.Focus.IdleCutoff)
+	if err != nil || cutoff < time.Minute {
+		return fmt.Errorf("invalid idle cutoff %q, use a duration like 8h", uc.Focus.IdleCutoff)
+	}
+	c.IdleCutoff = cutoff
+	return nil
+}
+
+// FocusIdleCutoff returns the configured idle cutoff of focus sessions.
+// Safe to call before InitConfig, falls back to DefaultIdleCutoff then.
+func (c *Config) FocusIdleCutoff() time.Duration {
+	if c == nil || c.IdleCutoff == 0 {
+		return DefaultIdleCutoff
+	}
+	return c.IdleCutoff
+}
+
 type UserConfig struct {
-	Name     string   `yaml:"name"`
-	Mode     string   `yaml:"mode"`
-	DbConfig DBConfig `yaml:"db_config"`
+	Name      string         `yaml:"name"`
+	Mode      string         `yaml:"mode"`
+	Timezone  string         `yaml:"timezone,omitempty"`   // IANA name, e.g. "Europe/Berlin", defaults to the system timezone
+	WeekStart string         `yaml:"week_start,omitempty"` // e.g. "monday" (default) or "sunday"
+	Reminders ReminderConfig `yaml:"reminders,omitempty"`
+	Focus     FocusConfig    `yaml:"focus,omitempty"`
+	DbConfig  DBConfig       `yaml:"db_config"`
 }
 
 func ValidateUserConfig(cmd *cobra.Command) {
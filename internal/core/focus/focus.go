@@ -4,19 +4,39 @@ import (
 	"errors"
 	"time"
 
+	"github.com/snehmatic/mindloop/internal/config"
+	"github.com/snehmatic/mindloop/internal/core/resolve"
 	"github.com/snehmatic/mindloop/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Service struct {
-	DB *gorm.DB
+	DB         *gorm.DB
+	IdleCutoff time.Duration // sessions open for longer are stale, see ListStale
 }
 
 func NewService(db *gorm.DB) *Service {
-	return &Service{DB: db}
+	return &Service{DB: db, IdleCutoff: config.GetConfig().FocusIdleCutoff()}
 }
 
-func (s *Service) StartSession(title string) (*models.FocusSession, error) {
+var (
+	ErrNotActive      = errors.New("focus session is not active")
+	ErrNotPaused      = errors.New("focus session is not paused")
+	ErrIntentNotFound = errors.New("intent not found")
+)
+
+// StartOptions tweaks how a focus session is started
+type StartOptions struct {
+	// IntentID links the session to an intent. Zero links it to the active
+	// intent if there is exactly one.
+	IntentID uint
+	// Planned is how long the session is meant to run, see EndOverdue.
+	// Zero leaves it open ended.
+	Planned time.Duration
+}
+
+func (s *Service) StartSession(title string, opts StartOptions) (*models.FocusSession, error) {
 	if title == "" {
 		return nil, errors.New("title cannot be empty")
 	}
@@ -25,40 +45,227 @@ func (s *Service) StartSession(title string) (*models.FocusSession, error) {
 		Title:  title,
 		Status: "active",
 	}
-
-	if err := s.DB.Create(session).Error; err != nil {
+	if err := s.start(session, opts); err != nil {
 		return nil, err
 	}
 	return session, nil
 }
 
+// intentFor finds the intent a new session works towards, nil if none
+func (s *Service) intentFor(opts StartOptions) (*models.Intent, error) {
+	if opts.IntentID != 0 {
+		var intent models.Intent
+		if err := s.DB.First(&intent, opts.IntentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrIntentNotFound
+			}
+			return nil, err
+		}
+		return &intent, nil
+	}
+	var active []models.Intent
+	if err := s.DB.Where("status = ?", "active").Limit(2).Find(&active).Error; err != nil {
+		return nil, err
+	}
+	if len(active) != 1 {
+		return nil, nil // none or several to pick from
+	}
+	return &active[0], nil
+}
+
+// start saves the new session along with its first segment
+func (s *Service) start(session *models.FocusSession, opts StartOptions) error {
+	if opts.Planned != 0 {
+		if opts.Planned < time.Minute {
+			return ErrPlanTooShort
+		}
+		session.PlannedMinutes = int(opts.Planned.Minutes())
+	}
+	intent, err := s.intentFor(opts)
+	if err != nil {
+		return err
+	}
+	if intent != nil {
+		session.IntentID = &intent.ID
+		session.Intent = intent
+	}
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(session).Error; err != nil {
+			return err
+		}
+		return startSegment(tx, session, session.CreatedAt)
+	})
+}
+
+// withSegments preloads the segments of sessions in order
+func withSegments(db *gorm.DB) *gorm.DB {
+	return db.Preload("Segments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("StartedAt")
+	})
+}
+
 func (s *Service) ListSessions() ([]models.FocusSession, error) {
 	var sessions []models.FocusSession
-	result := s.DB.Find(&sessions)
+	result := withSegments(s.DB).Preload("Intent").Find(&sessions)
 	return sessions, result.Error
 }
 
-func (s *Service) EndSession(id int) (*models.FocusSession, error) {
+// Resolve finds the ID of the focus session ref points at, see resolve.Resolve
+func (s *Service) Resolve(ref string) (int, error) {
+	return s.resolve(ref, s.DB)
+}
+
+// ResolveOpen is Resolve preferring active and paused sessions. Titles
+// repeat from day to day, e.g. "Deep work", so ending, pausing or resuming
+// one by title looks at the sessions that can be first. Only refs matching
+// no open session are resolved among all of them.
+func (s *Service) ResolveOpen(ref string) (int, error) {
+	id, err := s.resolve(ref, s.DB.Where("Status <> ?", "ended"))
+	if errors.Is(err, resolve.ErrNotFound) {
+		return s.Resolve(ref)
+	}
+	return id, err
+}
+
+func (s *Service) resolve(ref string, db *gorm.DB) (int, error) {
+	var sessions []models.FocusSession
+	if err := db.Select("id", "Title").Find(&sessions).Error; err != nil {
+		return 0, err
+	}
+	candidates := make([]resolve.Candidate, len(sessions))
+	for i, session := range sessions {
+		candidates[i] = resolve.Candidate{ID: session.ID, Title: session.Title}
+	}
+	id, err := resolve.Resolve(ref, candidates)
+	return int(id), err
+}
+
+func (s *Service) getSession(id int) (models.FocusSession, error) {
 	var session models.FocusSession
-	if err := s.DB.First(&session, id).Error; err != nil {
+	err := withSegments(s.DB).First(&session, id).Error
+	return session, err
+}
+
+// GetSession loads the session with its segments and intent
+func (s *Service) GetSession(id int) (*models.FocusSession, error) {
+	var session models.FocusSession
+	if err := withSegments(s.DB).Preload("Intent").First(&session, id).Error; err != nil {
 		return nil, err
 	}
+	return &session, nil
+}
+
+// startSegment starts a new segment of work in the session at at
+func startSegment(tx *gorm.DB, session *models.FocusSession, at time.Time) error {
+	seg := models.FocusSegment{FocusSessionID: session.ID, StartedAt: at}
+	if err := tx.Create(&seg).Error; err != nil {
+		return err
+	}
+	session.Segments = append(session.Segments, seg)
+	return nil
+}
+
+// endSegment ends the running segment of the session at at, if any
+func endSegment(tx *gorm.DB, session *models.FocusSession, at time.Time) error {
+	// Sessions started before segments were recorded ran since they were created
+	if len(session.Segments) == 0 {
+		seg := models.FocusSegment{FocusSessionID: session.ID, StartedAt: session.CreatedAt, EndedAt: &at}
+		if err := tx.Create(&seg).Error; err != nil {
+			return err
+		}
+		session.Segments = append(session.Segments, seg)
+		return nil
+	}
+	for i := range session.Segments {
+		seg := &session.Segments[i]
+		if seg.EndedAt == nil {
+			seg.EndedAt = &at
+			return tx.Model(seg).Update("EndedAt", at).Error
+		}
+	}
+	return nil
+}
 
+// PauseSession pauses an active session, the time until it is resumed
+// doesn't count towards its duration.
+func (s *Service) PauseSession(id int) (*models.FocusSession, error) {
+	session, err := s.getSession(id)
+	if err != nil {
+		return nil, err
+	}
 	if session.Status != "active" {
-		return nil, errors.New("focus session is not active")
+		return &session, ErrNotActive
 	}
 
-	session.Status = "ended"
-	session.EndTime = time.Now()
-	session.Duration = session.EndTime.Sub(session.CreatedAt).Minutes()
+	now := time.Now()
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := endSegment(tx, &session, now); err != nil {
+			return err
+		}
+		session.Status = "paused"
+		session.Duration = session.ActiveMinutes(now)
+		return tx.Omit(clause.Associations).Save(&session).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
 
-	if err := s.DB.Save(&session).Error; err != nil {
+// ResumeSession picks a paused session back up
+func (s *Service) ResumeSession(id int) (*models.FocusSession, error) {
+	session, err := s.getSession(id)
+	if err != nil {
 		return nil, err
 	}
+	if session.Status != "paused" {
+		return &session, ErrNotPaused
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := startSegment(tx, &session, time.Now()); err != nil {
+			return err
+		}
+		session.Status = "active"
+		return tx.Omit(clause.Associations).Save(&session).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// EndSession ends an active or paused session. Its duration is the time
+// worked in it, pauses left out.
+func (s *Service) EndSession(id int) (*models.FocusSession, error) {
+	session, err := s.getSession(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Status != "active" && session.Status != "paused" {
+		return nil, ErrNotActive
+	}
 
+	if err := s.end(&session, time.Now()); err != nil {
+		return nil, err
+	}
 	return &session, nil
 }
 
+// end ends the session at at
+func (s *Service) end(session *models.FocusSession, at time.Time) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := endSegment(tx, session, at); err != nil {
+			return err
+		}
+		session.Status = "ended"
+		session.EndTime = at
+		session.Duration = session.ActiveMinutes(at)
+		return tx.Omit(clause.Associations).Save(session).Error
+	})
+}
+
 func (s *Service) RateSession(id int, rating int) (*models.FocusSession, error) {
 	if rating < 0 || rating > 10 {
 		return nil, errors.New("rating must be between 0 and 10")
@@ -82,5 +289,10 @@ func (s *Service) RateSession(id int, rating int) (*models.FocusSession, error)
 }
 
 func (s *Service) DeleteAll() error {
-	return s.DB.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.FocusSession{}).Error
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.FocusSegment{}).Error; err != nil {
+			return err
+		}
+		return tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.FocusSession{}).Error
+	})
 }
@@ -0,0 +1,146 @@
+package focus
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/snehmatic/mindloop/internal/core/resolve"
+	"github.com/snehmatic/mindloop/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func newTestService(t *testing.T) *Service {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true, NoLowerCase: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect to test db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.FocusSession{}, &models.FocusSegment{}, &models.Intent{}); err != nil {
+		t.Fatalf("Failed to migrate test db: %v", err)
+	}
+	return NewService(db)
+}
+
+func TestPauseAndResume(t *testing.T) {
+	s := newTestService(t)
+	session, err := s.StartSession("Write docs", StartOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := int(session.ID)
+
+	if _, err := s.ResumeSession(id); !errors.Is(err, ErrNotPaused) {
+		t.Errorf("expected resuming an active session to fail, got %v", err)
+	}
+	if _, err := s.PauseSession(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.PauseSession(id); !errors.Is(err, ErrNotActive) {
+		t.Errorf("expected pausing a paused session to fail, got %v", err)
+	}
+
+	// 30 minutes of work, then a 60 minute lunch
+	now := time.Now()
+	s.DB.Model(&models.FocusSegment{}).Where("FocusSessionID = ?", id).
+		Updates(map[string]any{"StartedAt": now.Add(-90 * time.Minute), "EndedAt": now.Add(-60 * time.Minute)})
+	if _, err := s.ResumeSession(id); err != nil {
+		t.Fatal(err)
+	}
+	s.DB.Model(&models.FocusSegment{}).Where("FocusSessionID = ? AND EndedAt IS NULL", id).
+		Update("StartedAt", now.Add(-15*time.Minute))
+
+	session, err = s.EndSession(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(session.Segments) != 2 || math.Round(session.Duration) != 45 {
+		t.Errorf("expected 45 minutes over 2 segments, got %.1f over %d", session.Duration, len(session.Segments))
+	}
+	if got := models.ToFocusSessionView(*session, s.IdleCutoff).Duration; got != 45 {
+		t.Errorf("expected the view to show 45 minutes, got %.0f", got)
+	}
+}
+
+func TestEndSessionWithoutSegments(t *testing.T) {
+	s := newTestService(t)
+	// Sessions started before segments were recorded only have CreatedAt
+	session := &models.FocusSession{Title: "Old", Status: "active"}
+	session.CreatedAt = time.Now().Add(-20 * time.Minute)
+	if err := s.DB.Create(session).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := s.EndSession(int(session.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Round(session.Duration) != 20 || len(session.Segments) != 1 {
+		t.Errorf("expected a single 20 minute segment, got %.1f over %d", session.Duration, len(session.Segments))
+	}
+}
+
+func TestStartSessionIntent(t *testing.T) {
+	s := newTestService(t)
+	start := func(opts StartOptions) *models.FocusSession {
+		t.Helper()
+		session, err := s.StartSession("Work", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return session
+	}
+
+	if session := start(StartOptions{}); session.IntentID != nil {
+		t.Errorf("expected no intent without active intents, got %d", *session.IntentID)
+	}
+	api := &models.Intent{Name: "Ship the API", Status: "active"}
+	s.DB.Create(api)
+	if session := start(StartOptions{}); session.IntentID == nil || *session.IntentID != api.ID {
+		t.Errorf("expected the single active intent to be picked, got %v", session.IntentID)
+	}
+	docs := &models.Intent{Name: "Write docs", Status: "active"}
+	s.DB.Create(docs)
+	if session := start(StartOptions{}); session.IntentID != nil {
+		t.Errorf("expected no intent with several active ones, got %d", *session.IntentID)
+	}
+	if session := start(StartOptions{IntentID: docs.ID}); session.IntentID == nil || *session.IntentID != docs.ID {
+		t.Errorf("expected the given intent, got %v", session.IntentID)
+	}
+	if _, err := s.StartSession("Work", StartOptions{IntentID: 99}); !errors.Is(err, ErrIntentNotFound) {
+		t.Errorf("expected an unknown intent to be rejected, got %v", err)
+	}
+
+	var intent models.Intent
+	s.DB.Preload("FocusSessions.Segments").First(&intent, api.ID)
+	if len(intent.FocusSessions) != 1 {
+		t.Errorf("expected 1 session for the intent, got %d", len(intent.FocusSessions))
+	}
+}
+
+func TestResolveOpen(t *testing.T) {
+	s := newTestService(t)
+	yesterday, err := s.StartSession("Deep work", StartOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.EndSession(int(yesterday.ID)); err != nil {
+		t.Fatal(err)
+	}
+	today, err := s.StartSession("Deep work", StartOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ambiguous *resolve.AmbiguousError
+	if _, err := s.Resolve("deep work"); !errors.As(err, &ambiguous) {
+		t.Errorf("expected the title to be ambiguous among all sessions, got %v", err)
+	}
+	if id, err := s.ResolveOpen("deep work"); err != nil || id != int(today.ID) {
+		t.Errorf("expected today's session, got %d (%v)", id, err)
+	}
+}
@@ -0,0 +1,73 @@
+package focus
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/snehmatic/mindloop/models"
+)
+
+var (
+	ErrPlanTooShort    = errors.New("planned duration must be at least a minute")
+	ErrPlannedPomodoro = errors.New("pomodoro sessions follow their own plan, they can't have a planned duration too")
+)
+
+// Deadline is when the running session reaches its planned duration, given
+// the time already worked in it. It is zero for sessions without a plan and
+// for paused ones, the clock doesn't run while paused.
+func Deadline(session models.FocusSession) time.Time {
+	if !session.IsPlanned() || session.Status != "active" || len(session.Segments) == 0 {
+		return time.Time{}
+	}
+	running := session.Segments[len(session.Segments)-1]
+	if running.EndedAt != nil {
+		return time.Time{}
+	}
+	// Time worked before the running segment started
+	worked := session.ActiveMinutes(running.StartedAt)
+	left := time.Duration((float64(session.PlannedMinutes) - worked) * float64(time.Minute))
+	return running.StartedAt.Add(max(left, 0))
+}
+
+// EndOverdue ends the active sessions that reached their planned duration
+// by now, at the time they reached it, and returns them. It is run on each
+// CLI call and periodically by the server, so forgotten sessions don't run
+// on for hours.
+func (s *Service) EndOverdue(now time.Time) ([]models.FocusSession, error) {
+	var sessions []models.FocusSession
+	err := withSegments(s.DB).Where("Status = ? AND PlannedMinutes > 0", "active").Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	var ended []models.FocusSession
+	for _, session := range sessions {
+		deadline := Deadline(session)
+		if deadline.IsZero() || now.Before(deadline) {
+			continue
+		}
+		session.AutoEnded = true
+		if err := s.end(&session, deadline); err != nil {
+			return ended, err
+		}
+		ended = append(ended, session)
+	}
+	return ended, nil
+}
+
+// RunAutoEnd calls EndOverdue every interval until ctx is done. Errors are
+// passed to onError, nil to drop them.
+func (s *Service) RunAutoEnd(ctx context.Context, every time.Duration, onError func(error)) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		if _, err := s.EndOverdue(time.Now()); err != nil && onError != nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
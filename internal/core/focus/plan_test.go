@@ -0,0 +1,64 @@
+package focus
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/snehmatic/mindloop/models"
+)
+
+func TestEndOverdue(t *testing.T) {
+	s := newTestService(t)
+	now := time.Now()
+	// start starts a session and backdates it to have begun ago
+	start := func(title string, planned, ago time.Duration) *models.FocusSession {
+		t.Helper()
+		session, err := s.StartSession(title, StartOptions{Planned: planned})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.DB.Model(&models.FocusSegment{}).Where("FocusSessionID = ?", session.ID).Update("StartedAt", now.Add(-ago))
+		return session
+	}
+
+	if _, err := s.StartSession("Blink", StartOptions{Planned: time.Second}); !errors.Is(err, ErrPlanTooShort) {
+		t.Errorf("expected a plan under a minute to be rejected, got %v", err)
+	}
+
+	forgotten := start("Write RFC", 50*time.Minute, 14*time.Hour)
+	start("Review", 50*time.Minute, 20*time.Minute)
+	start("Open ended", 0, 14*time.Hour)
+	paused := start("Lunch break", 30*time.Minute, 2*time.Hour)
+	if _, err := s.PauseSession(int(paused.ID)); err != nil {
+		t.Fatal(err)
+	}
+
+	ended, err := s.EndOverdue(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ended) != 1 || ended[0].ID != forgotten.ID {
+		t.Fatalf("expected only the forgotten session to be ended, got %d", len(ended))
+	}
+	session, _ := s.getSession(int(forgotten.ID))
+	wantEnd := now.Add(-14 * time.Hour).Add(50 * time.Minute)
+	if !session.AutoEnded || session.Status != "ended" || math.Round(session.Duration) != 50 ||
+		session.EndTime.Sub(wantEnd).Abs() > time.Second {
+		t.Errorf("expected the session to end after its 50 planned minutes, got %+v", session)
+	}
+	if got := session.Plan(now); got != "50min, auto-ended" {
+		t.Errorf("unexpected plan %q", got)
+	}
+
+	// Ended by hand, 10 minutes late
+	late := start("Late", 50*time.Minute, 60*time.Minute)
+	late, err = s.EndSession(int(late.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := late.Plan(now); got != "50min, 10min over" {
+		t.Errorf("expected a 10 minute overrun, got %q", got)
+	}
+}
@@ -0,0 +1,125 @@
+package focus
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/snehmatic/mindloop/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Pomodoro is the plan of a pomodoro session: rounds of work, each but the
+// last followed by a break
+type Pomodoro struct {
+	Work   time.Duration
+	Break  time.Duration
+	Rounds int
+}
+
+// DefaultPomodoroRounds is the number of rounds when the plan leaves it out
+const DefaultPomodoroRounds = 4
+
+var ErrPomodoroFormat = errors.New(`invalid pomodoro plan, use work/break minutes and rounds, e.g. "25/5x4"`)
+
+// ParsePomodoro parses a plan like "25/5x4", 25 minutes of work and 5 of
+// break, 4 times. "25/5" runs DefaultPomodoroRounds rounds.
+func ParsePomodoro(s string) (Pomodoro, error) {
+	plan, rounds, hasRounds := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "x")
+	work, brk, ok := strings.Cut(plan, "/")
+	if !ok {
+		return Pomodoro{}, ErrPomodoroFormat
+	}
+	minutes := func(v string) (time.Duration, error) {
+		n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(v), "m"))
+		if err != nil || n < 1 || n > 240 {
+			return 0, ErrPomodoroFormat
+		}
+		return time.Duration(n) * time.Minute, nil
+	}
+
+	p := Pomodoro{Rounds: DefaultPomodoroRounds}
+	var err error
+	if p.Work, err = minutes(work); err != nil {
+		return Pomodoro{}, err
+	}
+	if p.Break, err = minutes(brk); err != nil {
+		return Pomodoro{}, err
+	}
+	if hasRounds {
+		p.Rounds, err = strconv.Atoi(strings.TrimSpace(rounds))
+		if err != nil || p.Rounds < 1 || p.Rounds > 24 {
+			return Pomodoro{}, ErrPomodoroFormat
+		}
+	}
+	return p, nil
+}
+
+// String formats the plan the way ParsePomodoro reads it
+func (p Pomodoro) String() string {
+	return fmt.Sprintf("%d/%dx%d", int(p.Work.Minutes()), int(p.Break.Minutes()), p.Rounds)
+}
+
+// StartPomodoro starts a focus session following the plan, in its first
+// work interval. The caller moves it along with CompleteInterval.
+func (s *Service) StartPomodoro(title string, plan Pomodoro, opts StartOptions) (*models.FocusSession, error) {
+	if title == "" {
+		return nil, errors.New("title cannot be empty")
+	}
+	if opts.Planned != 0 {
+		return nil, ErrPlannedPomodoro
+	}
+
+	session := &models.FocusSession{
+		Title:    title,
+		Status:   "active",
+		Pomodoro: plan.String(),
+	}
+	if err := s.start(session, opts); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// CompleteInterval ends the running interval of a pomodoro session, counting
+// a work interval as a pomodoro. With next it starts the following interval
+// right away: a break after work and work after a break.
+func (s *Service) CompleteInterval(id int, next bool) (*models.FocusSession, error) {
+	session, err := s.getSession(id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != "active" {
+		return &session, ErrNotActive
+	}
+	if len(session.Segments) == 0 || session.Segments[len(session.Segments)-1].EndedAt != nil {
+		return &session, errors.New("focus session has no running interval")
+	}
+
+	now := time.Now()
+	last := &session.Segments[len(session.Segments)-1]
+	wasBreak := last.Break
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		last.EndedAt = &now
+		last.Pomodoro = !wasBreak
+		if err := tx.Model(last).Updates(map[string]any{"EndedAt": now, "Pomodoro": last.Pomodoro}).Error; err != nil {
+			return err
+		}
+		if next {
+			seg := models.FocusSegment{FocusSessionID: session.ID, StartedAt: now, Break: !wasBreak}
+			if err := tx.Create(&seg).Error; err != nil {
+				return err
+			}
+			session.Segments = append(session.Segments, seg)
+		}
+		session.Duration = session.ActiveMinutes(now)
+		return tx.Omit(clause.Associations).Save(&session).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
@@ -0,0 +1,63 @@
+package focus
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePomodoro(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want string
+	}{
+		{"25/5x4", "25/5x4"},
+		{"50/10X2", "50/10x2"},
+		{"25m/5m", "25/5x4"},
+		{" 45/15 x 3 ", "45/15x3"},
+	} {
+		p, err := ParsePomodoro(tc.in)
+		if err != nil || p.String() != tc.want {
+			t.Errorf("ParsePomodoro(%q) = %v (%v), want %s", tc.in, p, err, tc.want)
+		}
+	}
+	for _, in := range []string{"", "25", "25/x4", "0/5", "25/5x0", "25/5x", "a/b"} {
+		if _, err := ParsePomodoro(in); err == nil {
+			t.Errorf("ParsePomodoro(%q) should fail", in)
+		}
+	}
+}
+
+func TestPomodoroIntervals(t *testing.T) {
+	s := newTestService(t)
+	plan, _ := ParsePomodoro("25/5x2")
+	session, err := s.StartPomodoro("Write report", plan, StartOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := int(session.ID)
+
+	// work, break, then part of the second work interval before Ctrl-C
+	if _, err := s.CompleteInterval(id, true); err != nil {
+		t.Fatal(err)
+	}
+	if session, err = s.CompleteInterval(id, true); err != nil {
+		t.Fatal(err)
+	}
+	segs := session.Segments
+	if len(segs) != 3 || segs[0].Break || !segs[0].Pomodoro || !segs[1].Break || segs[1].Pomodoro || segs[2].Break {
+		t.Fatalf("expected work, break and work segments, got %+v", segs)
+	}
+
+	// Breaks don't count as focus
+	s.DB.Model(&segs[1]).Update("StartedAt", segs[1].StartedAt.Add(-5*time.Minute))
+	session, err = s.EndSession(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Pomodoros() != 1 || session.Duration > 1 {
+		t.Errorf("expected 1 pomodoro and no break time, got %d and %.1f minutes", session.Pomodoros(), session.Duration)
+	}
+	if session.Pomodoro != "25/5x2" {
+		t.Errorf("expected the plan to be kept, got %q", session.Pomodoro)
+	}
+}
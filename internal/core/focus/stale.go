@@ -0,0 +1,107 @@
+package focus
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/snehmatic/mindloop/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrEndFormat  = errors.New(`invalid end time, use e.g. "18:30", "2006-01-02 18:30" or "2h" after the session started`)
+	ErrInvalidEnd = errors.New("end time must be between the start of the session and now")
+)
+
+// ListStale lists the open sessions that have run for longer than the idle
+// cutoff, oldest first. They were most likely never ended, see FixSession.
+func (s *Service) ListStale(now time.Time) ([]models.FocusSession, error) {
+	var sessions []models.FocusSession
+	err := withSegments(s.DB).Where("Status <> ?", "ended").Order("CreatedAt").Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	var stale []models.FocusSession
+	for _, session := range sessions {
+		if session.IsStale(now, s.IdleCutoff) {
+			stale = append(stale, session)
+		}
+	}
+	return stale, nil
+}
+
+// ParseEnd reads when a session really ended: a time of day like "18:30",
+// on the day the session started or the day after if that is earlier than
+// its start, a date and time like "2006-01-02 18:30", or a duration like
+// "2h" after the session started.
+func ParseEnd(input string, session models.FocusSession, loc *time.Location) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	if d, err := time.ParseDuration(input); err == nil {
+		if d <= 0 {
+			return time.Time{}, ErrEndFormat
+		}
+		return session.CreatedAt.Add(d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", input, loc); err == nil {
+		return t, nil
+	}
+	clock, err := time.ParseInLocation("15:04", input, loc)
+	if err != nil {
+		return time.Time{}, ErrEndFormat
+	}
+	start := session.CreatedAt.In(loc)
+	end := time.Date(start.Year(), start.Month(), start.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+	if end.Before(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end, nil
+}
+
+// FixSession sets when the session really ended, e.g. a stale one left
+// running for days. Work recorded after end is dropped. Ended sessions can
+// be fixed too.
+func (s *Service) FixSession(id int, end time.Time) (*models.FocusSession, error) {
+	session, err := s.getSession(id)
+	if err != nil {
+		return nil, err
+	}
+	if end.Before(session.CreatedAt) || end.After(time.Now()) {
+		return &session, ErrInvalidEnd
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if len(session.Segments) == 0 {
+			if err := endSegment(tx, &session, end); err != nil {
+				return err
+			}
+		}
+		var kept []models.FocusSegment
+		for _, seg := range session.Segments {
+			if !seg.StartedAt.Before(end) {
+				if err := tx.Delete(&seg).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if seg.EndedAt == nil || seg.EndedAt.After(end) {
+				seg.EndedAt = &end
+				if err := tx.Model(&seg).Update("EndedAt", end).Error; err != nil {
+					return err
+				}
+			}
+			kept = append(kept, seg)
+		}
+		session.Segments = kept
+		session.Status = "ended"
+		session.EndTime = end
+		session.AutoEnded = false
+		session.Duration = session.ActiveMinutes(end)
+		return tx.Omit(clause.Associations).Save(&session).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
@@ -0,0 +1,62 @@
+package focus
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/snehmatic/mindloop/models"
+)
+
+func TestFixSession(t *testing.T) {
+	s := newTestService(t)
+	now := time.Now()
+	started := now.Add(-72 * time.Hour)
+
+	// Left running for three days, with a pause after the first hour
+	session, err := s.StartSession("Write docs", StartOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := int(session.ID)
+	s.DB.Model(session).Update("CreatedAt", started)
+	s.DB.Model(&models.FocusSegment{}).Where("FocusSessionID = ?", id).
+		Updates(map[string]any{"StartedAt": started, "EndedAt": started.Add(time.Hour)})
+	s.DB.Create(&models.FocusSegment{FocusSessionID: session.ID, StartedAt: started.Add(90 * time.Minute)})
+
+	stale, err := s.ListStale(now)
+	if err != nil || len(stale) != 1 {
+		t.Fatalf("expected the session to be stale, got %d (%v)", len(stale), err)
+	}
+	if got := stale[0].FocusedMinutes(now, s.IdleCutoff); got != s.IdleCutoff.Minutes() {
+		t.Errorf("expected stats to count up to the idle cutoff, got %.0f minutes", got)
+	}
+
+	if _, err := s.FixSession(id, now.Add(time.Hour)); !errors.Is(err, ErrInvalidEnd) {
+		t.Errorf("expected an end in the future to be rejected, got %v", err)
+	}
+	end, err := ParseEnd("2h", stale[0], time.UTC)
+	if err != nil || !end.Equal(started.Add(2*time.Hour)) {
+		t.Fatalf("expected the end 2 hours after the start, got %v (%v)", end, err)
+	}
+	session, err = s.FixSession(id, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Status != "ended" || math.Round(session.Duration) != 90 || len(session.Segments) != 2 {
+		t.Errorf("expected 90 minutes of focus over 2 segments, got %.1f over %d", session.Duration, len(session.Segments))
+	}
+	if stale, _ := s.ListStale(now); len(stale) != 0 {
+		t.Errorf("expected no stale sessions once fixed, got %d", len(stale))
+	}
+
+	// A time of day earlier than the start falls on the next day
+	session.CreatedAt = time.Date(2026, 1, 2, 22, 0, 0, 0, time.UTC)
+	if end, err := ParseEnd("01:30", *session, time.UTC); err != nil || !end.Equal(time.Date(2026, 1, 3, 1, 30, 0, 0, time.UTC)) {
+		t.Errorf("expected 01:30 the next day, got %v (%v)", end, err)
+	}
+	if _, err := ParseEnd("soon", *session, time.UTC); !errors.Is(err, ErrEndFormat) {
+		t.Errorf("expected an unreadable end to be rejected, got %v", err)
+	}
+}
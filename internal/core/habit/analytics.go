@@ -0,0 +1,158 @@
+package habit
+
+import (
+	"time"
+
+	"github.com/snehmatic/mindloop/internal/period"
+	"github.com/snehmatic/mindloop/models"
+)
+
+// Analyze sums up the history of a habit from its logs and log events
+// (with HabitLog loaded, most recent first). The recent most recent events
+// are included as is, none if recent is negative.
+func Analyze(cal period.Calendar, h *models.Habit, logs []models.HabitLog, events []models.HabitEvent, recent int, now time.Time) models.HabitAnalytics {
+	a := models.HabitAnalytics{
+		Habit:  *h,
+		Streak: CalculateStreak(cal, h, logs, now),
+		Recent: models.ToHabitEventViews(*h, events[:max(0, min(recent, len(events)))]),
+	}
+
+	first := h.CreatedAt
+	completed := map[string]bool{}
+	relapsed := map[string]bool{}
+	skipped := map[string]bool{}
+	onTime := map[string]bool{}
+	for _, log := range logs {
+		if log.HabitID != h.ID {
+			continue
+		}
+		key := periodKey(log.EndedAt.In(cal.Location))
+		skipped[key] = log.Skipped
+		completed[key] = log.ActualCount >= log.TargetCount
+		onTime[key] = log.CompletedOnTime()
+		relapsed[key] = log.Relapses() > 0
+		if h.AsOf(log.EndedAt).IsAvoid() {
+			a.CheckIns += log.Relapses()
+		} else {
+			a.CheckIns += log.ActualCount
+		}
+		if log.EndedAt.Before(first) {
+			first = log.EndedAt
+		}
+	}
+
+	// Habits tracked per day are compared by the days they were done on,
+	// habits with longer periods by the days check-ins were logged on
+	current := PeriodFor(cal, h, now)
+	a.ByCheckIns = !current.Start.Equal(current.End)
+	weekdays := make([]models.WeekdayStats, 7)
+	for i := range weekdays {
+		weekdays[i].Weekday = (cal.WeekStart + time.Weekday(i)) % 7
+	}
+	weekday := func(t time.Time) *models.WeekdayStats {
+		return &weekdays[(int(t.In(cal.Location).Weekday())-int(cal.WeekStart)+7)%7]
+	}
+
+	var months []models.MonthStats
+	if !first.After(now) {
+		for _, p := range Periods(cal, h, first, now) {
+			key := periodKey(p.End)
+			if skipped[key] {
+				continue
+			}
+			isCurrent := p.End.Equal(current.End)
+			done, missed := completed[key], !isCurrent
+			def := h.AsOf(p.End)
+			if def.IsAvoid() {
+				done = !relapsed[key] && !isCurrent
+				missed = relapsed[key]
+			}
+			if !done && !missed {
+				continue // still open
+			}
+
+			a.PeriodsDue++
+			month := cal.Month(p.End).Start
+			if len(months) == 0 || !months[len(months)-1].Month.Equal(month) {
+				months = append(months, models.MonthStats{Month: month})
+			}
+			ms := &months[len(months)-1]
+			ms.PeriodsDue++
+			if p.Start.Equal(p.End) {
+				weekday(p.Start).Due++
+			}
+			if done {
+				a.Completed++
+				ms.Completed++
+				if onTime[key] && !def.IsAvoid() {
+					a.CompletedOnTime++
+				}
+				if p.Start.Equal(p.End) {
+					weekday(p.Start).Done++
+				}
+			}
+		}
+	}
+	for i := range months {
+		months[i].CompletionRate = float64(months[i].Completed) * 100 / float64(months[i].PeriodsDue)
+	}
+	if a.PeriodsDue > 0 {
+		a.CompletionRate = float64(a.Completed) * 100 / float64(a.PeriodsDue)
+	}
+	a.Months = months
+
+	for _, e := range events {
+		// Backfilled events were not logged on the day they count for
+		if p := (period.Period{Start: e.HabitLog.StartedAt, End: e.HabitLog.EndedAt}); p.Contains(e.CreatedAt) {
+			weekday(e.CreatedAt).CheckIns += e.Amount
+		}
+	}
+	a.Weekdays = weekdays
+	a.BestWeekday, a.WorstWeekday = bestAndWorst(weekdays, a.ByCheckIns, h.AsOf(now).IsAvoid())
+	return a
+}
+
+// bestAndWorst picks the best and worst weekday, nil if they can't be told
+// apart. Check-ins of habits to avoid are relapses, the fewer the better.
+func bestAndWorst(weekdays []models.WeekdayStats, byCheckIns, avoid bool) (best, worst *models.WeekdayStats) {
+	score := func(ws models.WeekdayStats) float64 {
+		if !byCheckIns {
+			return ws.Rate()
+		}
+		if avoid {
+			return -float64(ws.CheckIns)
+		}
+		return float64(ws.CheckIns)
+	}
+	for i := range weekdays {
+		ws := &weekdays[i]
+		if !byCheckIns && ws.Due == 0 {
+			continue // e.g. rest days of weekdays habits
+		}
+		if best == nil || score(*ws) > score(*best) {
+			best = ws
+		}
+		if worst == nil || score(*ws) < score(*worst) {
+			worst = ws
+		}
+	}
+	if best == nil || score(*best) == score(*worst) {
+		return nil, nil
+	}
+	return best, worst
+}
+
+// Analytics sums up the history of the habit, with the recent most recent
+// log events
+func (s *Service) Analytics(habitRef string, recent int) (*models.HabitAnalytics, error) {
+	habit, events, err := s.ListEvents(habitRef)
+	if err != nil {
+		return nil, err
+	}
+	var logs []models.HabitLog
+	if err := s.DB.Where("HabitID = ?", habit.ID).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	a := Analyze(s.Calendar, habit, logs, events, recent, time.Now())
+	return &a, nil
+}
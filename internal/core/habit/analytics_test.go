@@ -0,0 +1,42 @@
+package habit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/snehmatic/mindloop/models"
+	"gorm.io/gorm"
+)
+
+func TestAnalyze(t *testing.T) {
+	// 2026-10-05 is a Monday
+	h := models.Habit{Model: gorm.Model{ID: 1, CreatedAt: day("2026-10-05")}, Interval: models.Daily, TargetCount: 1}
+	var logs []models.HabitLog
+	for _, d := range []string{"2026-10-05", "2026-10-06", "2026-10-07", "2026-10-09", "2026-10-12"} {
+		logs = append(logs, doneLog(h, d))
+	}
+	events := []models.HabitEvent{
+		{Model: gorm.Model{ID: 2, CreatedAt: day("2026-10-12").Add(8 * time.Hour)}, HabitID: 1, Amount: 1, Note: "early"},
+		{Model: gorm.Model{ID: 1, CreatedAt: day("2026-10-09").Add(8 * time.Hour)}, HabitID: 1, Amount: 1},
+	}
+
+	// Today (10-14) is still open and doesn't count
+	a := Analyze(cal, &h, logs, events, 1, day("2026-10-14").Add(10*time.Hour))
+	if a.PeriodsDue != 9 || a.Completed != 5 || a.CheckIns != 5 {
+		t.Errorf("expected 5 of 9 periods completed, got %d of %d (%d check-ins)", a.Completed, a.PeriodsDue, a.CheckIns)
+	}
+	if len(a.Months) != 1 || a.Months[0].PeriodsDue != 9 || !a.Months[0].Month.Equal(day("2026-10-01")) {
+		t.Errorf("expected a single month, got %+v", a.Months)
+	}
+	if a.ByCheckIns || a.BestWeekday == nil || a.BestWeekday.Weekday != time.Monday || a.WorstWeekday.Weekday != time.Thursday {
+		t.Errorf("expected monday to be best and thursday worst, got %+v / %+v", a.BestWeekday, a.WorstWeekday)
+	}
+	if len(a.Recent) != 1 || a.Recent[0].Note != "early" {
+		t.Errorf("expected only the most recent event, got %+v", a.Recent)
+	}
+
+	// A negative count shows no events instead of panicking
+	if a := Analyze(cal, &h, logs, events, -1, day("2026-10-14")); len(a.Recent) != 0 {
+		t.Errorf("expected no events for a negative count, got %+v", a.Recent)
+	}
+}
@@ -0,0 +1,111 @@
+package habit
+
+import (
+	"strings"
+
+	"github.com/snehmatic/mindloop/models"
+	"gorm.io/gorm"
+)
+
+// importLegacyCount turns the count of a log written before events were
+// recorded into a single event, so the count survives syncCount.
+func importLegacyCount(tx *gorm.DB, habitLog *models.HabitLog) error {
+	if habitLog.ID == 0 || habitLog.ActualCount <= 0 {
+		return nil
+	}
+	var events int64
+	if err := tx.Model(&models.HabitEvent{}).Where("HabitLogID = ?", habitLog.ID).Count(&events).Error; err != nil {
+		return err
+	}
+	if events > 0 {
+		return nil
+	}
+	legacy := &models.HabitEvent{
+		Model:      gorm.Model{CreatedAt: habitLog.UpdatedAt},
+		HabitID:    habitLog.HabitID,
+		HabitLogID: habitLog.ID,
+		Amount:     habitLog.ActualCount,
+	}
+	return tx.Create(legacy).Error
+}
+
+// syncCount derives the log's ActualCount and LateCount from its events
+func syncCount(tx *gorm.DB, habitLog *models.HabitLog) error {
+	var counts struct{ Total, Late int }
+	err := tx.Model(&models.HabitEvent{}).
+		Where("HabitLogID = ?", habitLog.ID).
+		Select("COALESCE(SUM(Amount), 0) AS Total, COALESCE(SUM(CASE WHEN Late THEN Amount ELSE 0 END), 0) AS Late").
+		Scan(&counts).Error
+	if err != nil {
+		return err
+	}
+	habitLog.ActualCount = counts.Total
+	habitLog.LateCount = counts.Late
+	return tx.Model(habitLog).Updates(map[string]interface{}{"ActualCount": counts.Total, "LateCount": counts.Late}).Error
+}
+
+// ListEvents returns the log events of a habit, most recent first
+func (s *Service) ListEvents(habitRef string) (*models.Habit, []models.HabitEvent, error) {
+	habit, err := s.findHabit(habitRef)
+	if err != nil {
+		return nil, nil, err
+	}
+	var events []models.HabitEvent
+	err = s.DB.Preload("HabitLog").
+		Where("HabitID = ?", habit.ID).
+		Order("CreatedAt DESC, id DESC").
+		Find(&events).Error
+	return &habit, events, err
+}
+
+// LogNotes returns the notes of the logs' events by log ID, oldest first
+func (s *Service) LogNotes(logs []models.HabitLog) (map[uint][]string, error) {
+	ids := make([]uint, len(logs))
+	for i, log := range logs {
+		ids[i] = log.ID
+	}
+	notes := map[uint][]string{}
+	if len(ids) == 0 {
+		return notes, nil
+	}
+	var events []models.HabitEvent
+	err := s.DB.Where("HabitLogID IN ? AND Note <> ?", ids, "").
+		Order("CreatedAt, id").
+		Find(&events).Error
+	for _, e := range events {
+		notes[e.HabitLogID] = append(notes[e.HabitLogID], e.Note)
+	}
+	return notes, err
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern, so "100%" matches literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// SearchNotes finds the log events of the habits matching opts whose note
+// contains query, ignoring case, most recent first. Events of archived
+// habits are always included.
+func (s *Service) SearchNotes(query string, opts ListOptions) ([]models.HabitEvent, error) {
+	var events []models.HabitEvent
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return events, nil
+	}
+	pattern := "%" + likeEscaper.Replace(strings.ToLower(query)) + "%"
+	db := s.DB.Preload("HabitLog").Where(`LOWER(Note) LIKE ? ESCAPE '\'`, pattern)
+	if opts.Interval != "" || opts.Tag != "" {
+		habits, err := s.ListHabits(ListOptions{Interval: opts.Interval, Tag: opts.Tag, IncludeArchived: true})
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]uint, len(habits))
+		for i, h := range habits {
+			ids[i] = h.ID
+		}
+		if len(ids) == 0 {
+			return events, nil
+		}
+		db = db.Where("HabitID IN ?", ids)
+	}
+	err := db.Order("CreatedAt DESC, id DESC").Find(&events).Error
+	return events, err
+}
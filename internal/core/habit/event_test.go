@@ -0,0 +1,30 @@
+package habit
+
+import (
+	"testing"
+
+	"github.com/snehmatic/mindloop/models"
+)
+
+func TestSearchNotes(t *testing.T) {
+	s := newTestService(t)
+	if err := s.CreateHabit(&models.Habit{Title: "Run", TargetCount: 10, Interval: models.Daily}); err != nil {
+		t.Fatal(err)
+	}
+	for _, note := range []string{"5k at 100% effort", "5k at 1000 m altitude", `tempo_run C:\tracks`, "tempo run"} {
+		if _, _, err := s.LogHabit("run", LogOptions{Note: note}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Wildcards in the query match literally
+	for query, want := range map[string]int{"100%": 1, "tempo_run": 1, `:\tr`: 1, "TEMPO": 2, "%": 1} {
+		events, err := s.SearchNotes(query, ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != want {
+			t.Errorf("expected %d note(s) for %q, got %d", want, query, len(events))
+		}
+	}
+}
@@ -4,16 +4,21 @@ import (
 	"errors"
 	"time"
 
+	"github.com/snehmatic/mindloop/internal/config"
+	"github.com/snehmatic/mindloop/internal/core/resolve"
+	"github.com/snehmatic/mindloop/internal/period"
 	"github.com/snehmatic/mindloop/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Service struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	Calendar period.Calendar // decides where days, weeks and months begin
 }
 
 func NewService(db *gorm.DB) *Service {
-	return &Service{DB: db}
+	return &Service{DB: db, Calendar: config.GetConfig().Calendar()}
 }
 
 func (s *Service) CreateHabit(habit *models.Habit) error {
@@ -23,25 +28,80 @@ func (s *Service) CreateHabit(habit *models.Habit) error {
 	if err := habit.ValidateHabit(); err != nil {
 		return err
 	}
-	return s.DB.Create(habit).Error
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		return s.createHabit(tx, habit)
+	})
+}
+
+// createHabit creates the habit along with its first revision
+func (s *Service) createHabit(tx *gorm.DB, habit *models.Habit) error {
+	// New habits go to the end of the list
+	last, err := lastPosition(tx)
+	if err != nil {
+		return err
+	}
+	habit.Position = last + 1
+	if err := tx.Omit(clause.Associations).Create(habit).Error; err != nil {
+		return err
+	}
+	rev := models.NewRevision(*habit, s.Calendar.StartOfDay(habit.CreatedAt))
+	if err := tx.Create(&rev).Error; err != nil {
+		return err
+	}
+	habit.Revisions = []models.HabitRevision{rev}
+	return nil
+}
+
+// withDefinitions preloads what is needed to work out a habit's periods
+func withDefinitions(db *gorm.DB) *gorm.DB {
+	return db.Preload("Pauses").Preload("Revisions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("EffectiveFrom, id")
+	})
+}
+
+// Resolve finds the ID of the habit ref points at, see resolve.Resolve
+func (s *Service) Resolve(ref string) (uint, error) {
+	var habits []models.Habit
+	if err := s.DB.Find(&habits).Error; err != nil {
+		return 0, err
+	}
+	candidates := make([]resolve.Candidate, len(habits))
+	for i, h := range habits {
+		candidates[i] = resolve.Candidate{ID: h.ID, Title: h.Title}
+	}
+	return resolve.Resolve(ref, candidates)
 }
 
-func (s *Service) DeleteHabit(id string) error {
+// findHabit loads the habit ref points at, by ID, slug or title prefix
+func (s *Service) findHabit(ref string) (models.Habit, error) {
 	var habit models.Habit
-	if err := s.DB.First(&habit, "id = ?", id).Error; err != nil {
+	id, err := s.Resolve(ref)
+	if err != nil {
+		return habit, err
+	}
+	err = withDefinitions(s.DB).First(&habit, "id = ?", id).Error
+	return habit, err
+}
+
+func (s *Service) DeleteHabit(ref string) error {
+	habit, err := s.findHabit(ref)
+	if err != nil {
 		return err
 	}
 	return s.DB.Delete(&habit).Error
 }
 
-func (s *Service) GetHabit(id string) (*models.Habit, error) {
-	var habit models.Habit
-	if err := s.DB.First(&habit, "id = ?", id).Error; err != nil {
+func (s *Service) GetHabit(ref string) (*models.Habit, error) {
+	habit, err := s.findHabit(ref)
+	if err != nil {
 		return nil, err
 	}
 	return &habit, nil
 }
 
+// UpdateHabit saves the new definition of the habit as a revision that
+// takes effect today. Periods before today keep being judged against the
+// definition they were logged under.
 func (s *Service) UpdateHabit(habit *models.Habit) error {
 	if habit == nil {
 		return errors.New("habit cannot be nil")
@@ -49,126 +109,543 @@ func (s *Service) UpdateHabit(habit *models.Habit) error {
 	if err := habit.ValidateHabit(); err != nil {
 		return err
 	}
-	return s.DB.Save(habit).Error
+	var before models.Habit
+	if err := withDefinitions(s.DB).First(&before, "id = ?", habit.ID).Error; err != nil {
+		return err
+	}
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		return s.revise(tx, &before, habit)
+	})
 }
 
-func (s *Service) ListHabits(interval models.IntervalType) ([]models.Habit, error) {
-	var habits []models.Habit
-	query := s.DB
-	if interval != "" {
-		query = query.Where("interval = ?", interval)
+// revise records after as the revision of the habit effective today and
+// moves the logs of the running period over to it.
+func (s *Service) revise(tx *gorm.DB, before, after *models.Habit) error {
+	cal := s.Calendar
+	now := time.Now()
+	today := cal.StartOfDay(now)
+
+	// Habits created before revisions were kept start with their original definition
+	revisions := before.Revisions
+	if len(revisions) == 0 {
+		first := models.NewRevision(*before, cal.StartOfDay(before.CreatedAt))
+		if err := tx.Create(&first).Error; err != nil {
+			return err
+		}
+		revisions = []models.HabitRevision{first}
+	}
+
+	// Several edits on the same day make a single revision
+	last := revisions[len(revisions)-1]
+	rev := models.NewRevision(*after, today)
+	if !cal.StartOfDay(last.EffectiveFrom).Before(today) {
+		rev.Model = last.Model
+		rev.EffectiveFrom = last.EffectiveFrom
+		revisions = revisions[:len(revisions)-1]
 	}
-	result := query.Find(&habits)
-	return habits, result.Error
+	if err := tx.Save(&rev).Error; err != nil {
+		return err
+	}
+	after.Revisions = append(revisions, rev)
+
+	logs := func() *gorm.DB {
+		return tx.Model(&models.HabitLog{}).Where("HabitID = ?", before.ID)
+	}
+	if !rev.SameSchedule(last) {
+		// The period running under the old schedule ends yesterday, a log
+		// started today moves over to the period of the new schedule
+		err := logs().
+			Where("StartedAt < ? AND EndedAt >= ?", today, today).
+			Update("EndedAt", cal.AddDays(today, -1)).Error
+		if err != nil {
+			return err
+		}
+		current := PeriodFor(cal, after, now)
+		err = logs().
+			Where("StartedAt >= ?", today).
+			Updates(map[string]any{"StartedAt": current.Start, "EndedAt": current.End}).Error
+		if err != nil {
+			return err
+		}
+	}
+	// The running period is judged against the new definition
+	err := logs().
+		Where("EndedAt >= ?", today).
+		Updates(map[string]any{"Title": after.Title, "Interval": after.Interval, "TargetCount": after.TargetCount, "Unit": after.Unit}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Omit(clause.Associations).Save(after).Error
 }
 
-func (s *Service) LogHabit(habitID string) (*models.Habit, *models.HabitLog, error) {
-	var habit models.Habit
-	if err := s.DB.First(&habit, "id = ?", habitID).Error; err != nil {
-		return nil, nil, err
+// ListOptions filters the habits and logs to list
+type ListOptions struct {
+	Interval        models.IntervalType // empty lists every interval
+	Tag             string              // empty lists every tag
+	IncludeArchived bool                // archived habits are hidden unless set
+}
+
+// ListHabits lists the habits matching opts, pinned habits first and then
+// by position
+func (s *Service) ListHabits(opts ListOptions) ([]models.Habit, error) {
+	var habits []models.Habit
+	query := withDefinitions(s.DB).Order(listOrder)
+	if opts.Interval != "" {
+		query = query.Where("interval = ?", opts.Interval)
+	}
+	if !opts.IncludeArchived {
+		query = query.Where("ArchivedAt IS NULL")
+	}
+	if err := query.Find(&habits).Error; err != nil {
+		return nil, err
+	}
+	if opts.Tag == "" {
+		return habits, nil
 	}
+	// Tags are stored comma separated, matching them in Go keeps the
+	// query portable across databases
+	tagged := habits[:0]
+	for _, h := range habits {
+		if h.HasTag(opts.Tag) {
+			tagged = append(tagged, h)
+		}
+	}
+	return tagged, nil
+}
 
-	var existingLog models.HabitLog
-	today := time.Now().Truncate(24 * time.Hour)
-	endedAt := today
+var (
+	ErrAlreadyCompleted = errors.New("habit already completed for interval")
+	ErrNotScheduled     = errors.New("habit is not scheduled for that day")
+	ErrFutureDate       = errors.New("cannot log a habit for a future date")
+	ErrArchived         = errors.New("habit is archived")
+	ErrNotArchived      = errors.New("habit is not archived")
+	ErrAlreadyPaused    = errors.New("habit is already paused over those days")
+	ErrNotPaused        = errors.New("habit is not paused")
+	ErrNotSkipped       = errors.New("habit is not skipped for that period")
+)
+
+// ArchiveHabit hides the habit from lists and stops it being due, its
+// history is kept and still shows up in summaries.
+func (s *Service) ArchiveHabit(ref string) (*models.Habit, error) {
+	habit, err := s.findHabit(ref)
+	if err != nil {
+		return nil, err
+	}
+	if habit.IsArchived() {
+		return &habit, ErrArchived
+	}
+	now := time.Now()
+	habit.ArchivedAt = &now
+	if err := s.DB.Model(&habit).Update("ArchivedAt", habit.ArchivedAt).Error; err != nil {
+		return nil, err
+	}
+	return &habit, nil
+}
 
-	var res *gorm.DB
-	switch habit.Interval {
-	case models.Daily:
-		res = s.DB.Where("HabitID = ? AND EndedAt = ?", habit.ID, today).First(&existingLog)
-	case models.Weekly:
-		startOfWeek := time.Now().AddDate(0, 0, -int(time.Now().Weekday()))
-		endOfWeek := startOfWeek.AddDate(0, 0, 6).Truncate(24 * time.Hour)
-		endedAt = endOfWeek
-		res = s.DB.Where("HabitID = ? AND CreatedAt >= ? AND EndedAt <= ?", habit.ID, startOfWeek, endOfWeek).First(&existingLog)
+// UnarchiveHabit brings an archived habit back. The days it spent archived are not due.
+func (s *Service) UnarchiveHabit(ref string) (*models.Habit, error) {
+	habit, err := s.findHabit(ref)
+	if err != nil {
+		return nil, err
+	}
+	if !habit.IsArchived() {
+		return &habit, ErrNotArchived
 	}
 
-	if res.Error == nil {
-		// Log found
-		if existingLog.ActualCount >= habit.TargetCount {
-			return &habit, &existingLog, errors.New("habit already completed for interval")
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := coverArchivedDays(tx, s.Calendar, &habit); err != nil {
+			return err
+		}
+		// Moves left the archived habit's position behind, it comes back at the end
+		last, err := lastPosition(tx)
+		if err != nil {
+			return err
 		}
+		habit.Position = last + 1
+		return tx.Model(&habit).Updates(map[string]any{"ArchivedAt": nil, "Position": habit.Position}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	habit.ArchivedAt = nil
+	return &habit, nil
+}
+
+// coverArchivedDays pauses the habit over the days it spent archived, up
+// to yesterday, so they don't count as missed once it is back.
+func coverArchivedDays(tx *gorm.DB, cal period.Calendar, habit *models.Habit) error {
+	archivedFrom := cal.StartOfDay(*habit.ArchivedAt)
+	yesterday := cal.AddDays(time.Now(), -1)
+	if archivedFrom.After(yesterday) {
+		return nil
+	}
+	pause := &models.HabitPause{HabitID: habit.ID, StartedAt: archivedFrom, EndedAt: &yesterday, Reason: "archived"}
+	return tx.Create(pause).Error
+}
 
-		existingLog.ActualCount++
-		existingLog.EndedAt = endedAt
-		if err := s.DB.Save(&existingLog).Error; err != nil {
-			return nil, nil, err
+// PauseHabit pauses the habit from the day of from (today if zero) through
+// the day of until. A zero until pauses it until it is resumed.
+func (s *Service) PauseHabit(ref string, from, until time.Time, reason string) (*models.Habit, *models.HabitPause, error) {
+	habit, err := s.findHabit(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	if habit.IsArchived() {
+		return &habit, nil, ErrArchived
+	}
+
+	cal := s.Calendar
+	if from.IsZero() {
+		from = time.Now()
+	}
+	pause := &models.HabitPause{HabitID: habit.ID, StartedAt: cal.StartOfDay(from), Reason: reason}
+	span := period.Period{Start: pause.StartedAt, End: pause.StartedAt}
+	if !until.IsZero() {
+		end := cal.StartOfDay(until)
+		if end.Before(pause.StartedAt) {
+			return &habit, nil, errors.New("pause cannot end before it starts")
 		}
-		return &habit, &existingLog, nil
-	} else if !errors.Is(res.Error, gorm.ErrRecordNotFound) {
-		return nil, nil, res.Error
+		pause.EndedAt = &end
+		span.End = end
 	}
 
-	// Create new log
-	habitLog := &models.HabitLog{
-		HabitID:     habit.ID,
-		Title:       habit.Title,
-		Interval:    habit.Interval,
-		TargetCount: habit.TargetCount,
-		ActualCount: 1,
-		EndedAt:     endedAt,
+	for _, p := range habit.Pauses {
+		if overlaps(cal, p, span, pause.EndedAt == nil) {
+			return &habit, nil, ErrAlreadyPaused
+		}
 	}
-	if err := s.DB.Create(habitLog).Error; err != nil {
+	if err := s.DB.Create(pause).Error; err != nil {
 		return nil, nil, err
 	}
+	return &habit, pause, nil
+}
 
-	return &habit, habitLog, nil
+// overlaps reports whether the pause shares a day with span, open ended
+// spans run forever.
+func overlaps(cal period.Calendar, p models.HabitPause, span period.Period, openEnded bool) bool {
+	if p.EndedAt != nil && cal.StartOfDay(*p.EndedAt).Before(span.Start) {
+		return false
+	}
+	return openEnded || !cal.StartOfDay(p.StartedAt).After(span.End)
 }
 
-func (s *Service) UnlogHabit(habitID string) (*models.Habit, error) {
-	var habit models.Habit
-	if err := s.DB.First(&habit, "id = ?", habitID).Error; err != nil {
+// ResumeHabit ends the pause the habit is in today, so it is due again from today on.
+func (s *Service) ResumeHabit(ref string) (*models.Habit, error) {
+	habit, err := s.findHabit(ref)
+	if err != nil {
 		return nil, err
 	}
 
-	var existingLog models.HabitLog
-	today := time.Now().Format("2006-01-02")
-	var res *gorm.DB
+	cal := s.Calendar
+	today := cal.Day(time.Now())
+	pause := habit.PauseCovering(cal, today)
+	if pause == nil {
+		return &habit, ErrNotPaused
+	}
+
+	// A pause starting today never took effect
+	if !cal.StartOfDay(pause.StartedAt).Before(today.Start) {
+		return &habit, s.DB.Delete(pause).Error
+	}
+	yesterday := cal.AddDays(today.Start, -1)
+	return &habit, s.DB.Model(pause).Update("EndedAt", &yesterday).Error
+}
+
+// LogOptions tweaks how a habit log is recorded
+type LogOptions struct {
+	Date   time.Time // any day of the period to log for, zero means today
+	Amount int       // amount to add, e.g. 250 for a habit measured in ml, defaults to 1
+	Note   string    // optional note stored with the log event
+	// LastOnly makes UnlogHabit undo only the most recent event instead of the whole period
+	LastOnly bool
+}
+
+// periodFor resolves the period the options point at, rejecting future dates
+// and days the habit is not scheduled for.
+func (opts LogOptions) periodFor(cal period.Calendar, habit *models.Habit) (period.Period, error) {
+	now := time.Now()
+	date := opts.Date
+	if date.IsZero() {
+		date = now
+	}
+	if cal.StartOfDay(date).After(cal.StartOfDay(now)) {
+		return period.Period{}, ErrFutureDate
+	}
+	if !IsScheduled(cal, habit, date) {
+		return period.Period{}, ErrNotScheduled
+	}
+	return PeriodFor(cal, habit, date), nil
+}
+
+func (s *Service) LogHabit(habitRef string, opts LogOptions) (*models.Habit, *models.HabitLog, error) {
+	habit, err := s.findHabit(habitRef)
+	if err != nil {
+		return nil, nil, err
+	}
+	if habit.IsArchived() {
+		return &habit, nil, ErrArchived
+	}
+
+	logPeriod, err := opts.periodFor(s.Calendar, &habit)
+	if err != nil {
+		return &habit, nil, err
+	}
+	if opts.Amount < 0 {
+		return &habit, nil, errors.New("amount cannot be negative")
+	}
+	amount := max(opts.Amount, 1)
+	def := habit.AsOf(logPeriod.End) // backfilled periods are logged against the definition back then
 
-	switch habit.Interval {
-	case models.Daily:
-		res = s.DB.Where("HabitID = ? AND DATE(CreatedAt) = ?", habit.ID, today).First(&existingLog)
-	case models.Weekly:
-		startOfWeek := time.Now().AddDate(0, 0, -int(time.Now().Weekday()))
-		res = s.DB.Where("HabitID = ? AND CreatedAt >= ?", habit.ID, startOfWeek).First(&existingLog)
+	var habitLog models.HabitLog
+	res := s.DB.Where("HabitID = ? AND EndedAt = ?", habit.ID, logPeriod.End).First(&habitLog)
+	if res.Error == nil {
+		// Log found, measurable habits may go past their target and
+		// habits to avoid can relapse more than once
+		if habitLog.ActualCount >= def.TargetCount && !def.IsMeasurable() && !def.IsAvoid() {
+			return &habit, &habitLog, ErrAlreadyCompleted
+		}
+	} else if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		habitLog = models.HabitLog{
+			HabitID:     habit.ID,
+			Title:       def.Title,
+			Interval:    def.Interval,
+			TargetCount: def.TargetCount,
+			Unit:        def.Unit,
+		}
+	} else {
+		return nil, nil, res.Error
 	}
+	habitLog.StartedAt = logPeriod.Start
+	habitLog.EndedAt = logPeriod.End
+	// Logging a skipped period means it was not excused after all
+	habitLog.Skipped = false
+	habitLog.SkipReason = ""
 
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := importLegacyCount(tx, &habitLog); err != nil {
+			return err
+		}
+		if err := tx.Save(&habitLog).Error; err != nil {
+			return err
+		}
+		event := &models.HabitEvent{
+			HabitID:    habit.ID,
+			HabitLogID: habitLog.ID,
+			Amount:     amount,
+			Note:       opts.Note,
+			Late:       IsLate(s.Calendar, &habit, logPeriod, time.Now()),
+		}
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+		return syncCount(tx, &habitLog)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &habit, &habitLog, nil
+}
+
+// UnlogHabit undoes the log events of the period, only the most recent one
+// with opts.LastOnly.
+func (s *Service) UnlogHabit(habitRef string, opts LogOptions) (*models.Habit, *models.HabitLog, error) {
+	habit, err := s.findHabit(habitRef)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logPeriod, err := opts.periodFor(s.Calendar, &habit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var habitLog models.HabitLog
+	res := s.DB.Where("HabitID = ? AND EndedAt = ?", habit.ID, logPeriod.End).First(&habitLog)
 	if res.Error != nil {
 		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
-			return nil, errors.New("no existing log found for this habit")
+			return nil, nil, errors.New("no existing log found for this habit")
 		}
-		return nil, res.Error
+		return nil, nil, res.Error
 	}
 
-	if existingLog.ActualCount <= 0 {
-		return nil, errors.New("habit is already marked as undone")
+	if habitLog.ActualCount <= 0 {
+		return nil, nil, errors.New("habit is already marked as undone")
 	}
 
-	existingLog.ActualCount = 0 // resetting progress for the day/week.
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := importLegacyCount(tx, &habitLog); err != nil {
+			return err
+		}
+		events := tx.Where("HabitLogID = ?", habitLog.ID)
+		if opts.LastOnly {
+			var last models.HabitEvent
+			if err := events.Order("CreatedAt DESC, id DESC").First(&last).Error; err != nil {
+				return err
+			}
+			events = tx.Where("id = ?", last.ID)
+		}
+		if err := events.Delete(&models.HabitEvent{}).Error; err != nil {
+			return err
+		}
+		return syncCount(tx, &habitLog)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &habit, &habitLog, nil
+}
 
-	if err := s.DB.Save(&existingLog).Error; err != nil {
-		return nil, err
+// SkipHabit excuses the period of the day of date (today if zero), e.g.
+// when sick. Skipped periods are neither done nor missed.
+func (s *Service) SkipHabit(habitRef string, date time.Time, reason string) (*models.Habit, *models.HabitLog, error) {
+	habit, err := s.findHabit(habitRef)
+	if err != nil {
+		return nil, nil, err
+	}
+	if habit.IsArchived() {
+		return &habit, nil, ErrArchived
+	}
+	if len(reason) > 255 {
+		return &habit, nil, errors.New("skip reason cannot be longer than 255 characters")
 	}
 
-	return &habit, nil
+	logPeriod, err := LogOptions{Date: date}.periodFor(s.Calendar, &habit)
+	if err != nil {
+		return &habit, nil, err
+	}
+	def := habit.AsOf(logPeriod.End)
+
+	var habitLog models.HabitLog
+	res := s.DB.Where("HabitID = ? AND EndedAt = ?", habit.ID, logPeriod.End).First(&habitLog)
+	if res.Error == nil {
+		if !def.IsAvoid() && habitLog.ActualCount >= def.TargetCount {
+			return &habit, &habitLog, ErrAlreadyCompleted
+		}
+	} else if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		habitLog = models.HabitLog{
+			HabitID:     habit.ID,
+			Title:       def.Title,
+			Interval:    def.Interval,
+			TargetCount: def.TargetCount,
+			Unit:        def.Unit,
+			StartedAt:   logPeriod.Start,
+			EndedAt:     logPeriod.End,
+		}
+	} else {
+		return nil, nil, res.Error
+	}
+	habitLog.Skipped = true
+	habitLog.SkipReason = reason
+	if err := s.DB.Save(&habitLog).Error; err != nil {
+		return nil, nil, err
+	}
+	return &habit, &habitLog, nil
 }
 
-func (s *Service) ListHabitLogs(interval models.IntervalType) ([]models.HabitLog, error) {
+// UnskipHabit takes back the skip of the period of the day of date (today
+// if zero), the period is due again.
+func (s *Service) UnskipHabit(habitRef string, date time.Time) (*models.Habit, *models.HabitLog, error) {
+	habit, err := s.findHabit(habitRef)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logPeriod, err := LogOptions{Date: date}.periodFor(s.Calendar, &habit)
+	if err != nil {
+		return &habit, nil, err
+	}
+
+	var habitLog models.HabitLog
+	res := s.DB.Where("HabitID = ? AND EndedAt = ? AND Skipped = ?", habit.ID, logPeriod.End, true).First(&habitLog)
+	if res.Error != nil {
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			return &habit, nil, ErrNotSkipped
+		}
+		return nil, nil, res.Error
+	}
+
+	habitLog.Skipped = false
+	habitLog.SkipReason = ""
+	// A log that only recorded the skip has nothing left to keep
+	if habitLog.ActualCount == 0 {
+		err = s.DB.Delete(&habitLog).Error
+	} else {
+		err = s.DB.Model(&habitLog).Updates(map[string]interface{}{"Skipped": false, "SkipReason": ""}).Error
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return &habit, &habitLog, nil
+}
+
+// Progress returns the amount of the habit done in its period at now and
+// whether that period is settled, its target reached or the period skipped.
+// A habit to avoid is settled by a relapse only, a clean period is not over
+// before it ends. The habit needs its definitions loaded, see GetHabit.
+func (s *Service) Progress(h *models.Habit, now time.Time) (done int, settled bool, err error) {
+	current := PeriodFor(s.Calendar, h, now)
+	var logs []models.HabitLog
+	if err := s.DB.Where("HabitID = ? AND EndedAt = ?", h.ID, current.End).Find(&logs).Error; err != nil {
+		return 0, false, err
+	}
+	skipped, relapses := false, 0
+	for _, log := range logs {
+		done += log.ActualCount
+		relapses += log.Relapses()
+		skipped = skipped || log.Skipped
+	}
+	def := h.AsOf(now)
+	if def.IsAvoid() {
+		return done, skipped || relapses > 0, nil
+	}
+	return done, skipped || done >= def.TargetCount, nil
+}
+
+// ListHabitLogs lists the logs of the habits matching opts, newest period
+// first and in the order of the habits within a period. Logs of archived
+// habits are always included.
+func (s *Service) ListHabitLogs(opts ListOptions) ([]models.HabitLog, error) {
 	var habitLogs []models.HabitLog
+	habits, err := s.ListHabits(ListOptions{Tag: opts.Tag, IncludeArchived: true})
+	if err != nil {
+		return nil, err
+	}
 	query := s.DB
-	if interval != "" {
-		query = query.Where("interval = ?", interval)
+	if opts.Interval != "" {
+		query = query.Where("interval = ?", opts.Interval)
 	}
-	result := query.Order("CreatedAt DESC").Find(&habitLogs)
-	return habitLogs, result.Error
+	if opts.Tag != "" {
+		ids := make([]uint, len(habits))
+		for i, h := range habits {
+			ids[i] = h.ID
+		}
+		if len(ids) == 0 {
+			return habitLogs, nil
+		}
+		query = query.Where("HabitID IN ?", ids)
+	}
+	if err := query.Order("EndedAt DESC").Find(&habitLogs).Error; err != nil {
+		return nil, err
+	}
+	sortLogs(habitLogs, habits)
+	return habitLogs, nil
 }
 
 func (s *Service) DeleteAll() error {
 	// Transaction to delete both logs and habits
 	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.HabitEvent{}).Error; err != nil {
+			return err
+		}
 		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.HabitLog{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.HabitPause{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.HabitRevision{}).Error; err != nil {
+			return err
+		}
 		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Habit{}).Error; err != nil {
 			return err
 		}
@@ -0,0 +1,111 @@
+package habit
+
+import (
+	"time"
+
+	"github.com/snehmatic/mindloop/internal/period"
+	"github.com/snehmatic/mindloop/models"
+)
+
+// HeatmapWeeks is how far back heatmaps reach, a year
+const HeatmapWeeks = 53
+
+// HeatmapSpan returns the days a heatmap of the given number of weeks
+// ending on the day of now covers. It starts on a week start.
+func HeatmapSpan(cal period.Calendar, now time.Time, weeks int) period.Period {
+	start := cal.AddDays(cal.Week(now).Start, -7*(weeks-1))
+	return period.Period{Start: start, End: cal.StartOfDay(now)}
+}
+
+// HabitHeatmap returns a day per day of span, shaded by how much of the
+// target was reached in the period the day belongs to. For habits to avoid
+// periods without a relapse count as reached. Days of skipped periods are
+// not due.
+func HabitHeatmap(cal period.Calendar, h *models.Habit, logs []models.HabitLog, span period.Period) []models.HeatmapDay {
+	byEnd := map[int64]models.HabitLog{}
+	for _, log := range logs {
+		if log.HabitID == h.ID {
+			byEnd[log.EndedAt.Unix()] = log
+		}
+	}
+	created := cal.StartOfDay(h.CreatedAt)
+
+	days := make([]models.HeatmapDay, 0, cal.DaysBetween(span.Start, span.End)+1)
+	for cur := span.Start; !cur.After(span.End); {
+		p := PeriodFor(cal, h, cur)
+		def := h.AsOf(p.End)
+		due := IsScheduled(cal, h, p.Start) && h.PauseCovering(cal, p) == nil
+
+		log, logged := byEnd[p.End.Unix()]
+		ratio := 0.0
+		switch {
+		case def.IsAvoid():
+			if !logged || log.Relapses() == 0 {
+				ratio = 1
+			}
+		case logged:
+			target := log.TargetCount // as it was when logged
+			if target <= 0 {
+				target = def.TargetCount
+			}
+			ratio = min(float64(log.ActualCount)/float64(max(target, 1)), 1)
+		}
+
+		for d := cur; !d.After(p.End) && !d.After(span.End); d = cal.AddDays(d, 1) {
+			alive := !d.Before(created) && (h.ArchivedAt == nil || d.Before(*h.ArchivedAt))
+			days = append(days, models.HeatmapDay{Date: d, Due: due && alive && !log.Skipped, Ratio: ratio, Skipped: log.Skipped && alive})
+		}
+		cur = p.Until()
+	}
+	return days
+}
+
+// Heatmap returns the heatmap of a habit over the past weeks
+func (s *Service) Heatmap(habitRef string, weeks int) (*models.Habit, models.Heatmap, error) {
+	habit, err := s.findHabit(habitRef)
+	if err != nil {
+		return nil, models.Heatmap{}, err
+	}
+	span := HeatmapSpan(s.Calendar, time.Now(), weeks)
+	var logs []models.HabitLog
+	if err := s.DB.Where("HabitID = ? AND EndedAt >= ?", habit.ID, span.Start).Find(&logs).Error; err != nil {
+		return &habit, models.Heatmap{}, err
+	}
+	return &habit, models.Heatmap{Title: habit.Title, Days: HabitHeatmap(s.Calendar, &habit, logs, span)}, nil
+}
+
+// OverallHeatmap averages the heatmaps of all habits over the past weeks.
+// Archived habits count for the time they were active.
+func (s *Service) OverallHeatmap(weeks int) (models.Heatmap, error) {
+	habits, err := s.ListHabits(ListOptions{IncludeArchived: true})
+	if err != nil {
+		return models.Heatmap{}, err
+	}
+	span := HeatmapSpan(s.Calendar, time.Now(), weeks)
+	var logs []models.HabitLog
+	if err := s.DB.Where("EndedAt >= ?", span.Start).Find(&logs).Error; err != nil {
+		return models.Heatmap{}, err
+	}
+
+	var days []models.HeatmapDay
+	for d := span.Start; !d.After(span.End); d = s.Calendar.AddDays(d, 1) {
+		days = append(days, models.HeatmapDay{Date: d})
+	}
+	sums := make([]float64, len(days))
+	due := make([]int, len(days))
+	for i := range habits {
+		for j, d := range HabitHeatmap(s.Calendar, &habits[i], logs, span) {
+			if d.Due {
+				sums[j] += d.Ratio
+				due[j]++
+			}
+		}
+	}
+	for j := range days {
+		if due[j] > 0 {
+			days[j].Due = true
+			days[j].Ratio = sums[j] / float64(due[j])
+		}
+	}
+	return models.Heatmap{Title: "All habits", Days: days}, nil
+}
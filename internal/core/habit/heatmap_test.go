@@ -0,0 +1,34 @@
+package habit
+
+import (
+	"testing"
+
+	"github.com/snehmatic/mindloop/internal/period"
+	"github.com/snehmatic/mindloop/models"
+	"gorm.io/gorm"
+)
+
+func TestHabitHeatmap(t *testing.T) {
+	// Weekly from Wednesday 10-07, twice a week
+	h := models.Habit{Model: gorm.Model{ID: 1, CreatedAt: day("2026-10-07")}, Interval: models.Weekly, TargetCount: 2}
+	logs := []models.HabitLog{
+		{HabitID: 1, TargetCount: 2, ActualCount: 2, EndedAt: day("2026-10-11")},
+		{HabitID: 1, TargetCount: 2, ActualCount: 1, EndedAt: day("2026-10-18")},
+	}
+	span := period.Period{Start: day("2026-10-05"), End: day("2026-10-14")}
+
+	days := HabitHeatmap(cal, &h, logs, span)
+	if len(days) != 10 {
+		t.Fatalf("expected a day per day of the span, got %d", len(days))
+	}
+	if days[1].Due || !days[2].Due {
+		t.Errorf("expected days before the habit existed not to be due, got %+v", days[:3])
+	}
+	// Every day of a period gets the period's shade
+	if days[2].Level() != models.HeatmapLevels-1 || days[6].Level() != models.HeatmapLevels-1 {
+		t.Errorf("expected the first week to be done, got %+v", days[2:7])
+	}
+	if days[7].Ratio != 0.5 || days[9].Ratio != 0.5 {
+		t.Errorf("expected the second week to be half done, got %+v", days[7:])
+	}
+}
@@ -0,0 +1,294 @@
+package habit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/snehmatic/mindloop/internal/utils"
+	"github.com/snehmatic/mindloop/models"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// DefaultManifestPath is where habit sync looks for the manifest by default
+const DefaultManifestPath = ".mlrc"
+
+// Manifest is a YAML file declaring a set of habits, e.g.
+//
+//	habits:
+//	  - title: Drink water
+//	    target: 2000
+//	    unit: ml
+//	    tags: [health]
+//	  - title: Gym
+//	    interval: weekdays
+//	    weekdays: [mon, wed, fri]
+type Manifest struct {
+	Habits []ManifestHabit `yaml:"habits"`
+}
+
+// ManifestHabit declares a single habit. Omitted fields take the same
+// defaults as 'mindloop habit add'.
+type ManifestHabit struct {
+	Title       string   `yaml:"title"`
+	Description string   `yaml:"description,omitempty"`
+	Kind        string   `yaml:"kind,omitempty"` // build (default) or avoid
+	Interval    string   `yaml:"interval,omitempty"`
+	Target      int      `yaml:"target,omitempty"`
+	Every       int      `yaml:"every,omitempty"`    // every_n_days only
+	Weekdays    []string `yaml:"weekdays,omitempty"` // weekdays only
+	Unit        string   `yaml:"unit,omitempty"`
+	Tags        []string `yaml:"tags,omitempty"`
+	RemindAt    string   `yaml:"remind_at,omitempty"` // e.g. "07:30"
+	Window      string   `yaml:"window,omitempty"`    // e.g. "06:00-09:00" or "before 9am"
+}
+
+// LoadManifest reads and validates the manifest at path
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+
+	seen := map[string]bool{}
+	for _, mh := range m.Habits {
+		if _, err := mh.toHabit(); err != nil {
+			return nil, fmt.Errorf("invalid habit %q in %s: %w", mh.Title, path, err)
+		}
+		slug := utils.Slugify(mh.Title)
+		if seen[slug] {
+			return nil, fmt.Errorf("habit %q is defined more than once in %s", mh.Title, path)
+		}
+		seen[slug] = true
+	}
+	return &m, nil
+}
+
+// toHabit builds the habit the entry declares, with defaults filled in
+func (mh ManifestHabit) toHabit() (models.Habit, error) {
+	days, err := models.ParseWeekdays(strings.Join(mh.Weekdays, ","))
+	if err != nil {
+		return models.Habit{}, err
+	}
+	var remindAt string
+	if mh.RemindAt != "" {
+		if remindAt, err = models.ParseTimeOfDay(mh.RemindAt); err != nil {
+			return models.Habit{}, err
+		}
+	}
+	windowStart, windowEnd, err := models.ParseWindow(mh.Window)
+	if err != nil {
+		return models.Habit{}, err
+	}
+	h := models.Habit{
+		Title:       strings.TrimSpace(mh.Title),
+		Description: mh.Description,
+		Kind:        models.HabitKind(mh.Kind),
+		Interval:    models.IntervalType(mh.Interval),
+		TargetCount: mh.Target,
+		EveryN:      mh.Every,
+		Weekdays:    models.FormatWeekdays(days),
+		Unit:        mh.Unit,
+		Tags:        models.FormatTags(mh.Tags),
+		RemindAt:    remindAt,
+		WindowStart: windowStart,
+		WindowEnd:   windowEnd,
+	}
+	h.SetDefaults()
+	if !models.IsValidIntervalType(string(h.Interval)) {
+		return h, fmt.Errorf("invalid interval %q", mh.Interval)
+	}
+	return h, h.ValidateHabit()
+}
+
+// SyncAction is what habit sync does to a single habit
+type SyncAction string
+
+const (
+	SyncCreate  SyncAction = "create"
+	SyncUpdate  SyncAction = "update"
+	SyncArchive SyncAction = "archive"
+	SyncRestore SyncAction = "restore" // unarchive, and update if needed
+)
+
+// ErrSlugShared is returned by Sync when a manifest habit matches several
+// existing habits, e.g. "Read" and "read!", as it can't tell which one is meant
+var ErrSlugShared = errors.New("several habits share the slug of a manifest habit")
+
+// SyncChange is a single change needed to bring the database in line with the manifest
+type SyncChange struct {
+	Action SyncAction
+	Title  string
+	Diff   []string // changed fields, e.g. "target: 1 -> 2"
+}
+
+// Sync creates, updates and archives habits to match the manifest. Habits
+// are matched by slug. Existing habits not created by sync are adopted when
+// the manifest lists them, and left alone otherwise. With dryRun the
+// changes are only computed.
+func (s *Service) Sync(m *Manifest, dryRun bool) ([]SyncChange, error) {
+	var existing []models.Habit
+	if err := withDefinitions(s.DB).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	bySlug := map[string]*models.Habit{}
+	shared := map[string][]string{} // slug -> titles of the habits sharing it
+	for i := range existing {
+		slug := utils.Slugify(existing[i].Title)
+		if other, ok := bySlug[slug]; ok {
+			if len(shared[slug]) == 0 {
+				shared[slug] = []string{other.Title}
+			}
+			shared[slug] = append(shared[slug], existing[i].Title)
+		}
+		bySlug[slug] = &existing[i]
+	}
+
+	var changes []SyncChange
+	var apply []func(tx *gorm.DB) error
+	listed := map[uint]bool{}
+	for _, mh := range m.Habits {
+		want, err := mh.toHabit()
+		if err != nil {
+			return nil, fmt.Errorf("invalid habit %q: %w", mh.Title, err)
+		}
+
+		if titles := shared[utils.Slugify(want.Title)]; len(titles) > 0 {
+			return nil, fmt.Errorf("%w: %q matches %q, rename all but one", ErrSlugShared, want.Title, titles)
+		}
+		cur, ok := bySlug[utils.Slugify(want.Title)]
+		if !ok {
+			want.Managed = true
+			changes = append(changes, SyncChange{Action: SyncCreate, Title: want.Title, Diff: describeHabit(want)})
+			apply = append(apply, func(tx *gorm.DB) error { return s.createHabit(tx, &want) })
+			continue
+		}
+		listed[cur.ID] = true
+
+		diff := diffHabits(*cur, want)
+		if !cur.Managed {
+			diff = append(diff, "managed: no -> yes")
+		}
+		action := SyncUpdate
+		if cur.IsArchived() {
+			action = SyncRestore
+		} else if len(diff) == 0 {
+			continue
+		}
+		changes = append(changes, SyncChange{Action: action, Title: cur.Title, Diff: diff})
+
+		updated := *cur
+		updated.Title = want.Title
+		updated.Description = want.Description
+		updated.Kind = want.Kind
+		updated.Interval = want.Interval
+		updated.TargetCount = want.TargetCount
+		updated.EveryN = want.EveryN
+		updated.Weekdays = want.Weekdays
+		updated.Unit = want.Unit
+		updated.Tags = want.Tags
+		updated.RemindAt = want.RemindAt
+		updated.WindowStart = want.WindowStart
+		updated.WindowEnd = want.WindowEnd
+		updated.Managed = true
+		updated.ArchivedAt = nil
+		apply = append(apply, func(tx *gorm.DB) error {
+			if cur.IsArchived() {
+				if err := coverArchivedDays(tx, s.Calendar, cur); err != nil {
+					return err
+				}
+				last, err := lastPosition(tx)
+				if err != nil {
+					return err
+				}
+				updated.Position = last + 1
+			}
+			return s.revise(tx, cur, &updated)
+		})
+	}
+
+	// Managed habits that were dropped from the manifest are archived, not
+	// deleted, so their history stays around
+	for i := range existing {
+		h := existing[i]
+		if !h.Managed || h.IsArchived() || listed[h.ID] {
+			continue
+		}
+		changes = append(changes, SyncChange{Action: SyncArchive, Title: h.Title})
+		apply = append(apply, func(tx *gorm.DB) error {
+			return tx.Model(&h).Update("ArchivedAt", time.Now()).Error
+		})
+	}
+
+	if dryRun || len(apply) == 0 {
+		return changes, nil
+	}
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		for _, fn := range apply {
+			if err := fn(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return changes, err
+}
+
+// SyncFromFile loads the manifest at path, DefaultManifestPath if empty, and syncs it
+func (s *Service) SyncFromFile(path string, dryRun bool) ([]SyncChange, error) {
+	if path == "" {
+		path = DefaultManifestPath
+	}
+	m, err := LoadManifest(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("habit manifest %s not found", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.Sync(m, dryRun)
+}
+
+// describeHabit lists the fields of a habit about to be created
+func describeHabit(h models.Habit) []string {
+	desc := []string{"schedule: " + h.ScheduleLabel(), "target: " + h.FormatAmount(h.TargetCount)}
+	if h.IsAvoid() {
+		desc = []string{"schedule: " + h.ScheduleLabel(), "kind: avoid"}
+	}
+	if h.Description != "" {
+		desc = append(desc, "description: "+h.Description)
+	}
+	if h.Tags != "" {
+		desc = append(desc, "tags: "+h.Tags)
+	}
+	return desc
+}
+
+// diffHabits lists the manifest fields that differ between cur and want
+func diffHabits(cur, want models.Habit) []string {
+	var diff []string
+	field := func(name, from, to string) {
+		if from != to {
+			diff = append(diff, fmt.Sprintf("%s: %q -> %q", name, from, to))
+		}
+	}
+	field("title", cur.Title, want.Title)
+	field("description", cur.Description, want.Description)
+	field("kind", string(cur.Kind), string(want.Kind))
+	field("schedule", cur.ScheduleLabel(), want.ScheduleLabel())
+	if cur.TargetCount != want.TargetCount {
+		diff = append(diff, fmt.Sprintf("target: %d -> %d", cur.TargetCount, want.TargetCount))
+	}
+	field("unit", cur.Unit, want.Unit)
+	field("tags", cur.Tags, want.Tags)
+	field("reminder", cur.RemindAt, want.RemindAt)
+	field("window", cur.WindowLabel(), want.WindowLabel())
+	return diff
+}
@@ -0,0 +1,95 @@
+package habit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/snehmatic/mindloop/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func newTestService(t *testing.T) *Service {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true, NoLowerCase: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect to test db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Habit{}, &models.HabitLog{}, &models.HabitPause{}, &models.HabitEvent{}, &models.HabitRevision{}); err != nil {
+		t.Fatalf("Failed to migrate test db: %v", err)
+	}
+	return &Service{DB: db, Calendar: cal}
+}
+
+func TestSync(t *testing.T) {
+	s := newTestService(t)
+	if err := s.CreateHabit(&models.Habit{Title: "Drink Water", TargetCount: 1, Interval: models.Daily}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateHabit(&models.Habit{Title: "Journal", TargetCount: 1, Interval: models.Daily}); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &Manifest{Habits: []ManifestHabit{
+		{Title: "Drink water", Target: 2000, Unit: "ml"},
+		{Title: "Gym", Interval: "weekdays", Weekdays: []string{"mon", "fri"}},
+	}}
+
+	changes, err := s.Sync(m, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].Action != SyncUpdate || changes[1].Action != SyncCreate {
+		t.Fatalf("expected an update and a create, got %+v", changes)
+	}
+	var count int64
+	s.DB.Model(&models.Habit{}).Count(&count)
+	if count != 2 {
+		t.Fatalf("dry run must not change anything, got %d habits", count)
+	}
+
+	if _, err := s.Sync(m, false); err != nil {
+		t.Fatal(err)
+	}
+	water, err := s.GetHabit("drink-water")
+	if err != nil || water.TargetCount != 2000 || water.Unit != "ml" || !water.Managed {
+		t.Errorf("expected drink water to be adopted and updated, got %+v (%v)", water, err)
+	}
+	if changes, _ := s.Sync(m, true); len(changes) != 0 {
+		t.Errorf("expected no changes on a second sync, got %+v", changes)
+	}
+
+	// Dropping a managed habit archives it, unmanaged habits are left alone
+	m.Habits = m.Habits[1:]
+	changes, err = s.Sync(m, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Action != SyncArchive || changes[0].Title != "Drink water" {
+		t.Errorf("expected drink water to be archived, got %+v", changes)
+	}
+	if journal, _ := s.GetHabit("journal"); journal.IsArchived() {
+		t.Errorf("expected unmanaged habit to be left alone")
+	}
+}
+
+func TestSyncSharedSlug(t *testing.T) {
+	s := newTestService(t)
+	for _, title := range []string{"Read", "read!"} {
+		if err := s.CreateHabit(&models.Habit{Title: title, TargetCount: 1, Interval: models.Daily}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := &Manifest{Habits: []ManifestHabit{{Title: "Read", Target: 20, Unit: "pages"}}}
+	if _, err := s.Sync(m, true); !errors.Is(err, ErrSlugShared) {
+		t.Fatalf("expected the shared slug to be reported, got %v", err)
+	}
+	// Habits sharing a slug are fine as long as the manifest doesn't list them
+	m.Habits[0].Title = "Gym"
+	if _, err := s.Sync(m, true); err != nil {
+		t.Errorf("expected unrelated habits to sync, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package habit
+
+import (
+	"slices"
+
+	"github.com/snehmatic/mindloop/models"
+	"gorm.io/gorm"
+)
+
+// listOrder sorts habits the way they are listed: pinned habits first, then
+// by position. Habits created before positions were kept share position 0
+// and keep the order they were created in.
+const listOrder = "Pinned DESC, Position, id"
+
+// lastPosition returns the position of the habit at the end of the list,
+// archived habits included
+func lastPosition(tx *gorm.DB) (int, error) {
+	var last int
+	err := tx.Model(&models.Habit{}).Select("COALESCE(MAX(Position), 0)").Scan(&last).Error
+	return last, err
+}
+
+// MoveHabit moves the habit to position to, 1 being the top of the list of
+// active habits. Pinned habits always stay ahead of the others, so a habit
+// only moves among the habits pinned like it, and habits keep their place
+// among the others when pinned or unpinned. It returns the position the
+// habit ended up at.
+func (s *Service) MoveHabit(ref string, to int) (*models.Habit, int, error) {
+	habit, err := s.findHabit(ref)
+	if err != nil {
+		return nil, 0, err
+	}
+	if habit.IsArchived() {
+		return &habit, 0, ErrArchived
+	}
+
+	habits, err := s.ListHabits(ListOptions{})
+	if err != nil {
+		return nil, 0, err
+	}
+	var group, others []models.Habit
+	ahead := 0 // habits listed ahead of the group
+	for _, h := range habits {
+		if h.ID == habit.ID {
+			continue
+		}
+		if h.Pinned == habit.Pinned {
+			group = append(group, h)
+		} else if h.Pinned {
+			ahead++
+		}
+		others = append(others, h)
+	}
+	at := min(max(to-1-ahead, 0), len(group))
+
+	// Positions ignore pins, the habit goes right before the one it is
+	// moved ahead of, or right after the last of its group
+	slices.SortStableFunc(others, func(a, b models.Habit) int {
+		if a.Position != b.Position {
+			return a.Position - b.Position
+		}
+		return int(a.ID) - int(b.ID)
+	})
+	i := len(others)
+	if at < len(group) {
+		i = slices.IndexFunc(others, func(h models.Habit) bool { return h.ID == group[at].ID })
+	} else if len(group) > 0 {
+		i = slices.IndexFunc(others, func(h models.Habit) bool { return h.ID == group[len(group)-1].ID }) + 1
+	}
+	ordered := slices.Insert(others, i, habit)
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range ordered {
+			if ordered[i].Position == i+1 {
+				continue
+			}
+			if err := tx.Model(&ordered[i]).Update("Position", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	habit.Position = i + 1
+	return &habit, ahead + at + 1, nil
+}
+
+// MoveHabitBy moves the habit offset places down the list, up if negative
+func (s *Service) MoveHabitBy(ref string, offset int) (*models.Habit, int, error) {
+	habit, err := s.findHabit(ref)
+	if err != nil {
+		return nil, 0, err
+	}
+	if habit.IsArchived() {
+		return &habit, 0, ErrArchived
+	}
+	habits, err := s.ListHabits(ListOptions{})
+	if err != nil {
+		return nil, 0, err
+	}
+	i := slices.IndexFunc(habits, func(h models.Habit) bool { return h.ID == habit.ID })
+	return s.MoveHabit(ref, i+1+offset)
+}
+
+// PinHabit pins the habit to the top of lists, or unpins it
+func (s *Service) PinHabit(ref string, pinned bool) (*models.Habit, error) {
+	habit, err := s.findHabit(ref)
+	if err != nil {
+		return nil, err
+	}
+	habit.Pinned = pinned
+	if err := s.DB.Model(&habit).Update("Pinned", pinned).Error; err != nil {
+		return nil, err
+	}
+	return &habit, nil
+}
+
+// sortLogs orders logs of the same period like their habits are listed.
+// Logs of deleted habits come last.
+func sortLogs(logs []models.HabitLog, habits []models.Habit) {
+	rank := make(map[uint]int, len(habits))
+	for i, h := range habits {
+		rank[h.ID] = i
+	}
+	rankOf := func(id uint) int {
+		if r, ok := rank[id]; ok {
+			return r
+		}
+		return len(habits)
+	}
+	slices.SortStableFunc(logs, func(a, b models.HabitLog) int {
+		if !a.EndedAt.Equal(b.EndedAt) {
+			return b.EndedAt.Compare(a.EndedAt)
+		}
+		return rankOf(a.HabitID) - rankOf(b.HabitID)
+	})
+}
@@ -0,0 +1,89 @@
+package habit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/snehmatic/mindloop/models"
+)
+
+func TestMoveHabit(t *testing.T) {
+	s := newTestService(t)
+	for _, title := range []string{"Gym", "Read", "Walk"} {
+		if err := s.CreateHabit(&models.Habit{Title: title, TargetCount: 1, Interval: models.Daily}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	titles := func() string {
+		habits, err := s.ListHabits(ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		for _, h := range habits {
+			got += h.Title[:1]
+		}
+		return got
+	}
+
+	if _, pos, err := s.MoveHabit("walk", 1); err != nil || pos != 1 || titles() != "WGR" {
+		t.Fatalf("expected walk on top, got %s at %d (%v)", titles(), pos, err)
+	}
+	if _, pos, _ := s.MoveHabit("gym", 10); pos != 3 || titles() != "WRG" {
+		t.Errorf("expected gym at the bottom, got %s at %d", titles(), pos)
+	}
+
+	// Pinned habits stay on top, unpinned ones get their place back
+	if _, err := s.PinHabit("gym", true); err != nil || titles() != "GWR" {
+		t.Fatalf("expected gym pinned on top, got %s (%v)", titles(), err)
+	}
+	if _, pos, _ := s.MoveHabit("read", 1); pos != 2 || titles() != "GRW" {
+		t.Errorf("expected read not to move above the pinned habit, got %s at %d", titles(), pos)
+	}
+	s.PinHabit("gym", false)
+	if titles() != "RWG" {
+		t.Errorf("expected gym back at the bottom once unpinned, got %s", titles())
+	}
+
+	// Logs of the same period follow the order of their habits
+	for _, ref := range []string{"gym", "walk", "read"} {
+		if _, _, err := s.LogHabit(ref, LogOptions{Date: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	logs, err := s.ListHabitLogs(ListOptions{})
+	if err != nil || len(logs) != 3 || logs[0].Title != "Read" || logs[2].Title != "Gym" {
+		t.Errorf("expected logs in habit order, got %+v (%v)", logs, err)
+	}
+}
+
+func TestUnarchiveHabitPosition(t *testing.T) {
+	s := newTestService(t)
+	for _, title := range []string{"Gym", "Read", "Walk"} {
+		if err := s.CreateHabit(&models.Habit{Title: title, TargetCount: 1, Interval: models.Daily}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Moves renumber the active habits only, walk takes the position gym left
+	if _, err := s.ArchiveHabit("gym"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.MoveHabit("walk", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.UnarchiveHabit("gym"); err != nil {
+		t.Fatal(err)
+	}
+	habits, err := s.ListHabits(ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	for _, h := range habits {
+		got += h.Title[:1]
+	}
+	if got != "WRG" {
+		t.Errorf("expected gym back at the bottom, got %s", got)
+	}
+}
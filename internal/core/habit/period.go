@@ -0,0 +1,109 @@
+package habit
+
+import (
+	"slices"
+	"time"
+
+	"github.com/snehmatic/mindloop/internal/period"
+	"github.com/snehmatic/mindloop/models"
+)
+
+// PeriodFor returns the interval of the habit that contains t, as defined
+// by the revision in effect at t. The period's End is what gets stored as
+// HabitLog.EndedAt. Periods don't reach across a schedule change, the
+// period running when the schedule changed ends the day before.
+func PeriodFor(cal period.Calendar, h *models.Habit, t time.Time) period.Period {
+	def := h.AsOf(t)
+	from, until := scheduleSpan(cal, h, t)
+
+	anchor := h.CreatedAt
+	if !from.IsZero() {
+		anchor = from
+	}
+	p := schedulePeriod(cal, &def, t, anchor)
+	if !from.IsZero() && p.Start.Before(from) {
+		p.Start = from
+	}
+	if !until.IsZero() && !p.End.Before(until) {
+		p.End = cal.AddDays(until, -1)
+	}
+	return p
+}
+
+// schedulePeriod returns the period of a single definition containing t.
+// Every N days periods are counted from anchor.
+func schedulePeriod(cal period.Calendar, h *models.Habit, t, anchor time.Time) period.Period {
+	switch h.Interval {
+	case models.Weekly:
+		return cal.Week(t)
+	case models.Monthly:
+		return cal.Month(t)
+	case models.EveryNDays:
+		n := max(h.EveryN, 1)
+		offset := cal.DaysBetween(anchor, t) % n
+		if offset < 0 {
+			offset += n
+		}
+		return cal.Days(cal.AddDays(t, -offset), n)
+	}
+	// daily and weekdays habits are tracked per day
+	return cal.Day(t)
+}
+
+// scheduleSpan returns the first day the schedule in effect at t applies
+// and the first day it no longer does. Either is zero when the schedule
+// never changed in that direction.
+func scheduleSpan(cal period.Calendar, h *models.Habit, t time.Time) (from, until time.Time) {
+	revs := h.Revisions
+	i := max(h.RevisionAt(t), 0)
+	if i >= len(revs) {
+		return
+	}
+	for j := i; j > 0; j-- {
+		if !revs[j-1].SameSchedule(revs[i]) {
+			from = cal.StartOfDay(revs[j].EffectiveFrom)
+			break
+		}
+	}
+	for k := i + 1; k < len(revs); k++ {
+		if !revs[k].SameSchedule(revs[i]) {
+			until = cal.StartOfDay(revs[k].EffectiveFrom)
+			break
+		}
+	}
+	return
+}
+
+// IsScheduled reports whether the habit is due in the period containing t.
+// Only weekdays habits have periods that are not due.
+func IsScheduled(cal period.Calendar, h *models.Habit, t time.Time) bool {
+	def := h.AsOf(t)
+	if def.Interval != models.OnWeekdays {
+		return true
+	}
+	return slices.Contains(def.ScheduledWeekdays(), t.In(cal.Location).Weekday())
+}
+
+// Periods returns the scheduled periods of the habit overlapping [from, to].
+// Paused periods are left out, as is everything after the habit was archived.
+func Periods(cal period.Calendar, h *models.Habit, from, to time.Time) []period.Period {
+	if h.ArchivedAt != nil && h.ArchivedAt.Before(to) {
+		to = *h.ArchivedAt
+	}
+	var periods []period.Period
+	for cur := from; !cur.After(to); {
+		p := PeriodFor(cal, h, cur)
+		if IsScheduled(cal, h, p.Start) && h.PauseCovering(cal, p) == nil {
+			periods = append(periods, p)
+		}
+		cur = p.Until()
+	}
+	return periods
+}
+
+// IsLate reports whether a log made at for period p is outside the habit's
+// time window. Backfilled logs don't say when the habit was done, they are
+// taken as on time.
+func IsLate(cal period.Calendar, h *models.Habit, p period.Period, at time.Time) bool {
+	return h.HasWindow() && p.Contains(at) && !h.InWindow(cal, at)
+}
@@ -0,0 +1,186 @@
+package habit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/snehmatic/mindloop/internal/period"
+	"github.com/snehmatic/mindloop/models"
+	"gorm.io/gorm"
+)
+
+var cal = period.New(time.UTC, time.Monday)
+
+func day(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestPeriodFor(t *testing.T) {
+	created := gorm.Model{ID: 1, CreatedAt: day("2026-10-01")}
+	// Wednesday 2026-10-07, mid-morning
+	now := day("2026-10-07").Add(10 * time.Hour)
+
+	tests := []struct {
+		name       string
+		habit      models.Habit
+		start, end string
+	}{
+		{"daily", models.Habit{Model: created, Interval: models.Daily}, "2026-10-07", "2026-10-07"},
+		{"weekly", models.Habit{Model: created, Interval: models.Weekly}, "2026-10-05", "2026-10-11"},
+		{"monthly", models.Habit{Model: created, Interval: models.Monthly}, "2026-10-01", "2026-10-31"},
+		{"every 3 days", models.Habit{Model: created, Interval: models.EveryNDays, EveryN: 3}, "2026-10-07", "2026-10-09"},
+		{"weekdays", models.Habit{Model: created, Interval: models.OnWeekdays, Weekdays: "mon,wed,fri"}, "2026-10-07", "2026-10-07"},
+	}
+	for _, tt := range tests {
+		p := PeriodFor(cal, &tt.habit, now)
+		if !p.Start.Equal(day(tt.start)) || !p.End.Equal(day(tt.end)) {
+			t.Errorf("%s: expected %s to %s, got %+v", tt.name, tt.start, tt.end, p)
+		}
+	}
+}
+
+func TestIsScheduled(t *testing.T) {
+	h := models.Habit{Interval: models.OnWeekdays, Weekdays: "mon,wed,fri"}
+	if !IsScheduled(cal, &h, day("2026-10-07")) || IsScheduled(cal, &h, day("2026-10-06")) {
+		t.Error("expected the habit to be due on Wednesday only, not Tuesday")
+	}
+	if daily := (models.Habit{Interval: models.Daily}); !IsScheduled(cal, &daily, day("2026-10-06")) {
+		t.Error("expected daily habits to always be due")
+	}
+}
+
+func TestPeriods(t *testing.T) {
+	created := gorm.Model{ID: 1, CreatedAt: day("2026-10-01")}
+	weekdays := models.Habit{Model: created, Interval: models.OnWeekdays, Weekdays: "mon,wed,fri"}
+	if got := Periods(cal, &weekdays, day("2026-10-05"), day("2026-10-11")); len(got) != 3 {
+		t.Errorf("expected 3 scheduled days in the week, got %d", len(got))
+	}
+	weekly := models.Habit{Model: created, Interval: models.Weekly}
+	got := Periods(cal, &weekly, day("2026-10-01"), day("2026-10-20"))
+	if len(got) != 4 || !got[0].Start.Equal(day("2026-09-28")) {
+		t.Errorf("expected 4 weeks starting Monday 2026-09-28, got %+v", got)
+	}
+}
+
+func TestPeriodForRevisions(t *testing.T) {
+	// Daily from 10-01, weekly with a target of 3 from Thursday 10-08
+	h := models.Habit{Model: gorm.Model{ID: 1, CreatedAt: day("2026-10-01")}, Interval: models.Weekly, TargetCount: 3}
+	h.Revisions = []models.HabitRevision{
+		{EffectiveFrom: day("2026-10-01"), Interval: models.Daily, TargetCount: 1},
+		{EffectiveFrom: day("2026-10-08"), Interval: models.Weekly, TargetCount: 3},
+	}
+
+	if p := PeriodFor(cal, &h, day("2026-10-03")); !p.Start.Equal(day("2026-10-03")) || !p.End.Equal(day("2026-10-03")) {
+		t.Errorf("expected a daily period before the change, got %+v", p)
+	}
+	if def := h.AsOf(day("2026-10-03")); def.Interval != models.Daily || def.TargetCount != 1 {
+		t.Errorf("expected the daily definition before the change, got %+v", def)
+	}
+
+	// The first week starts on the day of the change, not the Monday before
+	if p := PeriodFor(cal, &h, day("2026-10-09")); !p.Start.Equal(day("2026-10-08")) || !p.End.Equal(day("2026-10-11")) {
+		t.Errorf("expected the week to be clipped to the change, got %+v", p)
+	}
+	if p := PeriodFor(cal, &h, day("2026-10-13")); !p.Start.Equal(day("2026-10-12")) || !p.End.Equal(day("2026-10-18")) {
+		t.Errorf("expected a full week after the change, got %+v", p)
+	}
+
+	// Daily logs before the change still count towards the streak
+	logs := []models.HabitLog{
+		doneLog(h, "2026-10-05"), doneLog(h, "2026-10-06"), doneLog(h, "2026-10-07"),
+		{HabitID: 1, TargetCount: 3, ActualCount: 3, EndedAt: day("2026-10-11")},
+	}
+	streak := CalculateStreak(cal, &h, logs, day("2026-10-13"))
+	if streak.Current != 4 {
+		t.Errorf("expected current 4 across the change, got %+v", streak)
+	}
+}
+
+func TestIsLate(t *testing.T) {
+	h := models.Habit{Interval: models.Weekly, TargetCount: 1, WindowStart: "06:00", WindowEnd: "09:00"}
+	week := cal.Week(day("2026-10-05"))
+	for at, late := range map[string]bool{
+		"2026-10-07T05:59:00Z": true,
+		"2026-10-07T06:00:00Z": false,
+		"2026-10-07T08:59:00Z": false,
+		"2026-10-07T09:00:00Z": true,
+		"2026-10-13T07:00:00Z": false, // the week is over, a backfill
+	} {
+		now, _ := time.Parse(time.RFC3339, at)
+		if got := IsLate(cal, &h, week, now); got != late {
+			t.Errorf("IsLate at %s: expected %v, got %v", at, late, got)
+		}
+	}
+
+	// Open ended windows
+	h.WindowStart = ""
+	if IsLate(cal, &h, week, week.Start.Add(8*time.Hour)) {
+		t.Errorf("expected 08:00 to be before 09:00")
+	}
+	h.WindowStart, h.WindowEnd = "22:00", ""
+	if !IsLate(cal, &h, week, week.Start.Add(8*time.Hour)) {
+		t.Errorf("expected 08:00 to be late for a habit to do after 22:00")
+	}
+}
+
+func TestLogHabitLate(t *testing.T) {
+	s := newTestService(t)
+	// Before midnight never comes, so logging today is always late
+	h := &models.Habit{Title: "Meditate", TargetCount: 1, Interval: models.Daily, WindowEnd: "00:00"}
+	if err := s.CreateHabit(h); err != nil {
+		t.Fatal(err)
+	}
+
+	_, log, err := s.LogHabit("meditate", LogOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log.LateCount != 1 || log.CompletedOnTime() {
+		t.Errorf("expected the log to be flagged as late, got %+v", log)
+	}
+
+	_, log, err = s.LogHabit("meditate", LogOptions{Date: s.Calendar.AddDays(time.Now(), -1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log.LateCount != 0 || !log.CompletedOnTime() {
+		t.Errorf("expected a backfilled log to count as on time, got %+v", log)
+	}
+}
+
+func TestSkipHabitRevisedTarget(t *testing.T) {
+	s := newTestService(t)
+	if err := s.CreateHabit(&models.Habit{Title: "Read", TargetCount: 2, Interval: models.Daily}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.LogHabit("read", LogOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	revise := func(target int) {
+		t.Helper()
+		h, err := s.findHabit("read")
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.TargetCount = target
+		if err := s.UpdateHabit(&h); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// One read of a target lowered to 1 is done, nothing to skip
+	revise(1)
+	if _, _, err := s.SkipHabit("read", time.Time{}, "sick"); err != ErrAlreadyCompleted {
+		t.Errorf("expected the period to be completed against the revised target, got %v", err)
+	}
+
+	revise(3)
+	_, log, err := s.SkipHabit("read", time.Time{}, "sick")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !log.Skipped || log.ActualCount != 1 {
+		t.Errorf("expected the period to be skipped with its read kept, got %+v", log)
+	}
+}
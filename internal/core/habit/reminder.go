@@ -0,0 +1,120 @@
+package habit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/snehmatic/mindloop/internal/notify"
+	"github.com/snehmatic/mindloop/models"
+)
+
+// Clock tells the scheduler the time, tests use a fake one
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// Scheduler sends reminders for habits with a reminder time that are not
+// done yet in their current period. Each habit is reminded at most once a
+// day, also across runs as the day is kept on the habit. A reminder that
+// failed to send is retried on the next tick.
+type Scheduler struct {
+	Habits   *Service
+	Notifier notify.Notifier
+	Clock    Clock // system clock if nil
+}
+
+func NewScheduler(habits *Service, notifier notify.Notifier) *Scheduler {
+	return &Scheduler{Habits: habits, Notifier: notifier, Clock: systemClock{}}
+}
+
+// Tick sends the reminders due by now and returns the ones sent
+func (sc *Scheduler) Tick(ctx context.Context) ([]notify.Notification, error) {
+	if sc.Clock == nil {
+		sc.Clock = systemClock{}
+	}
+	cal := sc.Habits.Calendar
+	now := sc.Clock.Now()
+	today := cal.Day(now)
+
+	habits, err := sc.Habits.ListHabits(ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var sent []notify.Notification
+	var errs []error
+	for _, h := range habits {
+		due := h.ReminderOn(cal, now)
+		if due.IsZero() || now.Before(due) || (h.RemindedOn != nil && !h.RemindedOn.Before(today.Start)) {
+			continue
+		}
+		def := h.AsOf(now)
+		if def.IsAvoid() || !IsScheduled(cal, &h, now) || h.PauseCovering(cal, today) != nil {
+			continue
+		}
+
+		done, settled, err := sc.Habits.Progress(&h, now)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if settled {
+			continue
+		}
+
+		n := notify.Notification{
+			HabitID: h.ID,
+			Title:   def.Title,
+			Message: fmt.Sprintf("%s of %s done %s", def.FormatAmount(done), def.FormatAmount(def.TargetCount), periodLabel(def.Interval)),
+			Due:     due,
+		}
+		if err := sc.Notifier.Notify(ctx, n); err != nil {
+			errs = append(errs, fmt.Errorf("reminding of %q: %w", def.Title, err))
+			continue
+		}
+		// UpdateColumn leaves UpdatedAt alone, a reminder is no edit
+		if err := sc.Habits.DB.Model(&h).UpdateColumn("RemindedOn", today.Start).Error; err != nil {
+			errs = append(errs, err)
+		}
+		sent = append(sent, n)
+	}
+	return sent, errors.Join(errs...)
+}
+
+// Run calls Tick every interval until ctx is done. Errors are passed to
+// onError, nil to drop them. It returns right away if every is not positive.
+func (sc *Scheduler) Run(ctx context.Context, every time.Duration, onError func(error)) {
+	if every <= 0 {
+		return
+	}
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		if _, err := sc.Tick(ctx); err != nil && onError != nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// periodLabel names the current period of an interval, e.g. "this week"
+func periodLabel(interval models.IntervalType) string {
+	switch interval {
+	case models.Daily, models.OnWeekdays:
+		return "today"
+	case models.Weekly:
+		return "this week"
+	case models.Monthly:
+		return "this month"
+	}
+	return "this period"
+}
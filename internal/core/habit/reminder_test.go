@@ -0,0 +1,126 @@
+package habit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/snehmatic/mindloop/internal/notify"
+	"github.com/snehmatic/mindloop/models"
+)
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func TestSchedulerReminders(t *testing.T) {
+	s := newTestService(t)
+	for _, h := range []*models.Habit{
+		{Title: "Drink water", TargetCount: 2000, Unit: "ml", Interval: models.Daily, RemindAt: "09:00"},
+		{Title: "Gym", TargetCount: 1, Interval: models.Daily, RemindAt: "07:00"},
+		{Title: "Read", TargetCount: 1, Interval: models.Daily},
+	} {
+		if err := s.CreateHabit(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := s.LogHabit("gym", LogOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var received []notify.Notification
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n notify.Notification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			t.Errorf("invalid webhook payload: %v", err)
+		}
+		if status == http.StatusOK {
+			received = append(received, n)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	today := cal.StartOfDay(time.Now())
+	clock := &fakeClock{now: today.Add(8 * time.Hour)}
+	sc := &Scheduler{Habits: s, Notifier: notify.Webhook{URL: srv.URL}, Clock: clock}
+	tick := func() []notify.Notification {
+		t.Helper()
+		sent, err := sc.Tick(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sent
+	}
+
+	// Gym is already done, water is not due before 09:00
+	if sent := tick(); len(sent) != 0 {
+		t.Fatalf("expected no reminders at 08:00, got %+v", sent)
+	}
+
+	// A failed delivery is retried on the next tick
+	clock.now = today.Add(9*time.Hour + 30*time.Minute)
+	status = http.StatusInternalServerError
+	if _, err := sc.Tick(context.Background()); err == nil {
+		t.Fatal("expected the failing webhook to be reported")
+	}
+	status = http.StatusOK
+	if sent := tick(); len(sent) != 1 || sent[0].Title != "Drink water" {
+		t.Fatalf("expected a reminder for drink water, got %+v", sent)
+	}
+	if len(received) != 1 || received[0].Message != "0 ml of 2000 ml done today" {
+		t.Errorf("expected the webhook to receive the reminder, got %+v", received)
+	}
+
+	// Once a day only
+	clock.now = clock.now.Add(time.Hour)
+	if sent := tick(); len(sent) != 0 {
+		t.Errorf("expected no second reminder the same day, got %+v", sent)
+	}
+	clock.now = clock.now.AddDate(0, 0, 1)
+	if sent := tick(); len(sent) != 2 {
+		t.Errorf("expected both habits to be reminded of the next day, got %+v", sent)
+	}
+}
+
+func TestSchedulerRemembersReminders(t *testing.T) {
+	s := newTestService(t)
+	if err := s.CreateHabit(&models.Habit{Title: "Read", TargetCount: 1, Interval: models.Daily, RemindAt: "09:00"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each run of "habit remind --once" has a scheduler of its own
+	clock := &fakeClock{now: cal.StartOfDay(time.Now()).Add(10 * time.Hour)}
+	for i, want := range []int{1, 0} {
+		sc := &Scheduler{Habits: s, Notifier: notify.Stdout{Out: io.Discard}, Clock: clock}
+		sent, err := sc.Tick(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sent) != want {
+			t.Errorf("run %d: expected %d reminders, got %+v", i+1, want, sent)
+		}
+	}
+}
+
+func TestSchedulerRunNonPositiveInterval(t *testing.T) {
+	s := newTestService(t)
+	sc := &Scheduler{Habits: s, Notifier: notify.Stdout{Out: io.Discard}, Clock: &fakeClock{now: time.Now()}}
+	for _, every := range []time.Duration{0, -time.Minute} {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			sc.Run(context.Background(), every, nil)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("expected Run to return right away with an interval of %s", every)
+		}
+	}
+}
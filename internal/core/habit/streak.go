@@ -0,0 +1,102 @@
+package habit
+
+import (
+	"time"
+
+	"github.com/snehmatic/mindloop/internal/period"
+	"github.com/snehmatic/mindloop/models"
+)
+
+// periodKey identifies a period by its last day, the same day stored as HabitLog.EndedAt.
+func periodKey(end time.Time) string {
+	return end.Format("2006-01-02")
+}
+
+// CalculateStreak walks the scheduled periods of a habit from its first
+// period up to now. A missed period resets the run, periods the habit is
+// not scheduled for are skipped, as are periods skipped on purpose. The
+// current period only extends the streak once completed, an unfinished
+// current period does not break it. Habits to avoid complete a period by
+// getting through it without a relapse, so the streak counts clean periods.
+func CalculateStreak(cal period.Calendar, h *models.Habit, logs []models.HabitLog, now time.Time) models.HabitStreak {
+	var streak models.HabitStreak
+
+	first := h.CreatedAt
+	completed := map[string]bool{}
+	relapsed := map[string]bool{}
+	skipped := map[string]bool{}
+	for _, log := range logs {
+		if log.HabitID != h.ID {
+			continue
+		}
+		key := periodKey(log.EndedAt.In(cal.Location))
+		skipped[key] = log.Skipped
+		if log.ActualCount >= log.TargetCount {
+			completed[key] = true
+		}
+		if log.Relapses() > 0 {
+			relapsed[key] = true
+		}
+		if h.IsAvoid() && log.Relapses() > 0 {
+			if relapse := lastDay(cal, log, now); relapse.After(streak.LastRelapse) {
+				streak.LastRelapse = relapse
+			}
+		}
+		if log.EndedAt.Before(first) {
+			first = log.EndedAt
+		}
+	}
+	if h.IsAvoid() {
+		clean := first
+		if !streak.LastRelapse.IsZero() {
+			clean = streak.LastRelapse
+		}
+		streak.DaysSinceRelapse = max(cal.DaysBetween(clean, now), 0)
+	}
+	if first.After(now) {
+		return streak
+	}
+
+	current := PeriodFor(cal, h, now)
+	run := 0
+	for _, p := range Periods(cal, h, first, now) {
+		key := periodKey(p.End)
+		if skipped[key] {
+			continue // excused, neither done nor missed
+		}
+		isCurrent := p.End.Equal(current.End)
+		done, missed := completed[key], !isCurrent
+		if def := h.AsOf(p.End); def.IsAvoid() {
+			done = !relapsed[key] && !isCurrent
+			missed = relapsed[key]
+		}
+		if done {
+			run++
+			streak.Longest = max(streak.Longest, run)
+			streak.LastCompleted = p.Start
+			continue
+		}
+		if missed {
+			run = 0
+		}
+	}
+	streak.Current = run
+	return streak
+}
+
+// lastDay returns the last day of the log's period that is not in the future
+func lastDay(cal period.Calendar, log models.HabitLog, now time.Time) time.Time {
+	if today := cal.StartOfDay(now); log.EndedAt.After(today) {
+		return today
+	}
+	return cal.StartOfDay(log.EndedAt)
+}
+
+// GetStreak returns the current and longest streak of the habit.
+func (s *Service) GetStreak(habit *models.Habit) (models.HabitStreak, error) {
+	var logs []models.HabitLog
+	if err := s.DB.Where("HabitID = ?", habit.ID).Find(&logs).Error; err != nil {
+		return models.HabitStreak{}, err
+	}
+	return CalculateStreak(s.Calendar, habit, logs, time.Now()), nil
+}
@@ -0,0 +1,125 @@
+package habit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/snehmatic/mindloop/models"
+	"gorm.io/gorm"
+)
+
+func doneLog(h models.Habit, end string) models.HabitLog {
+	return models.HabitLog{HabitID: h.ID, TargetCount: 1, ActualCount: 1, EndedAt: day(end)}
+}
+
+func TestCalculateStreakDaily(t *testing.T) {
+	h := models.Habit{Model: gorm.Model{ID: 1, CreatedAt: day("2026-10-01")}, Interval: models.Daily, TargetCount: 1}
+	logs := []models.HabitLog{
+		doneLog(h, "2026-10-01"), doneLog(h, "2026-10-02"), doneLog(h, "2026-10-03"),
+		// 10-04 missed
+		doneLog(h, "2026-10-05"), doneLog(h, "2026-10-06"),
+	}
+
+	// Today (10-07) is not logged yet, that must not break the streak
+	now := day("2026-10-07").Add(10 * time.Hour)
+	streak := CalculateStreak(cal, &h, logs, now)
+	if streak.Current != 2 || streak.Longest != 3 {
+		t.Errorf("expected current 2 longest 3, got %+v", streak)
+	}
+	if !streak.LastCompleted.Equal(day("2026-10-06")) {
+		t.Errorf("expected last completed 2026-10-06, got %v", streak.LastCompleted)
+	}
+
+	// Missing yesterday as well resets the current streak
+	streak = CalculateStreak(cal, &h, logs, now.AddDate(0, 0, 1))
+	if streak.Current != 0 || streak.Longest != 3 {
+		t.Errorf("expected current 0 longest 3, got %+v", streak)
+	}
+}
+
+func TestCalculateStreakWeekdays(t *testing.T) {
+	// 2026-10-05 is a Monday
+	h := models.Habit{Model: gorm.Model{ID: 1, CreatedAt: day("2026-10-05")}, Interval: models.OnWeekdays, Weekdays: "mon,wed,fri", TargetCount: 1}
+	logs := []models.HabitLog{
+		doneLog(h, "2026-10-05"), doneLog(h, "2026-10-07"), doneLog(h, "2026-10-09"), doneLog(h, "2026-10-12"),
+	}
+
+	// Rest days in between don't count as misses
+	streak := CalculateStreak(cal, &h, logs, day("2026-10-13"))
+	if streak.Current != 4 || streak.Longest != 4 {
+		t.Errorf("expected current 4 longest 4, got %+v", streak)
+	}
+}
+
+func TestCalculateStreakPausedAndArchived(t *testing.T) {
+	h := models.Habit{Model: gorm.Model{ID: 1, CreatedAt: day("2026-10-01")}, Interval: models.Daily, TargetCount: 1}
+	until := day("2026-10-05")
+	h.Pauses = []models.HabitPause{{HabitID: 1, StartedAt: day("2026-10-03"), EndedAt: &until}}
+	logs := []models.HabitLog{
+		doneLog(h, "2026-10-01"), doneLog(h, "2026-10-02"),
+		// 10-03 to 10-05 on vacation
+		doneLog(h, "2026-10-06"),
+	}
+
+	streak := CalculateStreak(cal, &h, logs, day("2026-10-06").Add(10*time.Hour))
+	if streak.Current != 3 || streak.Longest != 3 {
+		t.Errorf("expected paused days to be skipped, got %+v", streak)
+	}
+
+	// Nothing is due after archiving, so the streak is kept as it was
+	archivedAt := day("2026-10-06").Add(20 * time.Hour)
+	h.ArchivedAt = &archivedAt
+	streak = CalculateStreak(cal, &h, logs, day("2026-10-20"))
+	if streak.Current != 3 {
+		t.Errorf("expected archived habit to keep its streak, got %+v", streak)
+	}
+}
+
+func TestCalculateStreakSkipped(t *testing.T) {
+	h := models.Habit{Model: gorm.Model{ID: 1, CreatedAt: day("2026-10-01")}, Interval: models.Daily, TargetCount: 1}
+	sick := models.HabitLog{HabitID: h.ID, TargetCount: 1, EndedAt: day("2026-10-03"), Skipped: true, SkipReason: "sick"}
+	logs := []models.HabitLog{doneLog(h, "2026-10-01"), doneLog(h, "2026-10-02"), sick, doneLog(h, "2026-10-04")}
+
+	// The skipped day neither extends nor breaks the streak
+	streak := CalculateStreak(cal, &h, logs, day("2026-10-04").Add(10*time.Hour))
+	if streak.Current != 3 || streak.Longest != 3 {
+		t.Errorf("expected skipped day to be left out, got %+v", streak)
+	}
+}
+
+func TestCalculateStreakAvoid(t *testing.T) {
+	h := models.Habit{Model: gorm.Model{ID: 1, CreatedAt: day("2026-10-01")}, Kind: models.KindAvoid, Interval: models.Daily}
+	relapse := func(d string) models.HabitLog {
+		return models.HabitLog{HabitID: h.ID, ActualCount: 1, EndedAt: day(d)}
+	}
+
+	// Clean from 10-01 to 10-06, today (10-07) is not over yet
+	now := day("2026-10-07").Add(10 * time.Hour)
+	streak := CalculateStreak(cal, &h, nil, now)
+	if streak.Current != 6 || streak.DaysSinceRelapse != 6 {
+		t.Errorf("expected 6 clean days, got %+v", streak)
+	}
+
+	streak = CalculateStreak(cal, &h, []models.HabitLog{relapse("2026-10-04")}, now)
+	if streak.Current != 2 || streak.Longest != 3 || streak.DaysSinceRelapse != 3 {
+		t.Errorf("expected current 2 longest 3 and 3 days since relapse, got %+v", streak)
+	}
+
+	// A relapse today ends the run right away
+	streak = CalculateStreak(cal, &h, []models.HabitLog{relapse("2026-10-07")}, now)
+	if streak.Current != 0 || streak.Longest != 6 || streak.DaysSinceRelapse != 0 {
+		t.Errorf("expected the relapse today to reset the streak, got %+v", streak)
+	}
+
+	// With a time window, e.g. "no screens after 10pm", relapses outside it don't count
+	h.Title, h.WindowStart = "No screens", "22:00"
+	if err := h.ValidateHabit(); err != nil {
+		t.Fatalf("expected a habit to avoid to take a time window, got %v", err)
+	}
+	outside := relapse("2026-10-04")
+	outside.LateCount = outside.ActualCount
+	streak = CalculateStreak(cal, &h, []models.HabitLog{outside}, now)
+	if streak.Current != 6 || !streak.LastRelapse.IsZero() {
+		t.Errorf("expected the relapse outside the window to be ignored, got %+v", streak)
+	}
+}
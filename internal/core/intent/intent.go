@@ -4,8 +4,10 @@ import (
 	"errors"
 	"time"
 
+	"github.com/snehmatic/mindloop/internal/core/resolve"
 	"github.com/snehmatic/mindloop/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Service struct {
@@ -32,21 +34,44 @@ func (s *Service) StartIntent(name string) (*models.Intent, error) {
 	return intent, nil
 }
 
+// withFocusSessions preloads the focus sessions of intents, to add up the time focused on them
+func withFocusSessions(db *gorm.DB) *gorm.DB {
+	return db.Preload("FocusSessions.Segments")
+}
+
 func (s *Service) ListIntents() ([]models.Intent, error) {
 	var intents []models.Intent
-	result := s.DB.Find(&intents)
+	result := withFocusSessions(s.DB).Find(&intents)
 	return intents, result.Error
 }
 
 func (s *Service) ListActiveIntents() ([]models.Intent, error) {
 	var intents []models.Intent
-	result := s.DB.Where("status = ?", "active").Find(&intents)
+	result := withFocusSessions(s.DB).Where("status = ?", "active").Find(&intents)
 	return intents, result.Error
 }
 
-func (s *Service) EndIntent(idStr string) (*models.Intent, error) {
+// Resolve finds the ID of the intent ref points at, see resolve.Resolve
+func (s *Service) Resolve(ref string) (uint, error) {
+	var intents []models.Intent
+	if err := s.DB.Find(&intents).Error; err != nil {
+		return 0, err
+	}
+	candidates := make([]resolve.Candidate, len(intents))
+	for i, intent := range intents {
+		candidates[i] = resolve.Candidate{ID: intent.ID, Title: intent.Name}
+	}
+	return resolve.Resolve(ref, candidates)
+}
+
+func (s *Service) EndIntent(ref string) (*models.Intent, error) {
+	id, err := s.Resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+
 	var intent models.Intent
-	if err := s.DB.Where("id = ?", idStr).First(&intent).Error; err != nil {
+	if err := withFocusSessions(s.DB).Where("id = ?", id).First(&intent).Error; err != nil {
 		return nil, err
 	}
 
@@ -54,7 +79,7 @@ func (s *Service) EndIntent(idStr string) (*models.Intent, error) {
 	intent.Status = "done"
 	intent.EndedAt = &now
 
-	if err := s.DB.Save(&intent).Error; err != nil {
+	if err := s.DB.Omit(clause.Associations).Save(&intent).Error; err != nil {
 		return nil, err
 	}
 
@@ -3,6 +3,7 @@ package journal
 import (
 	"errors"
 
+	"github.com/snehmatic/mindloop/internal/core/resolve"
 	"github.com/snehmatic/mindloop/models"
 	"gorm.io/gorm"
 )
@@ -41,13 +42,34 @@ func (s *Service) ListEntries() ([]models.JournalEntry, error) {
 	return entries, result.Error
 }
 
-func (s *Service) GetEntry(id string) (models.JournalEntry, error) {
+// Resolve finds the ID of the journal entry ref points at, see resolve.Resolve
+func (s *Service) Resolve(ref string) (uint, error) {
+	var entries []models.JournalEntry
+	if err := s.DB.Order("CreatedAt DESC").Find(&entries).Error; err != nil {
+		return 0, err
+	}
+	candidates := make([]resolve.Candidate, len(entries))
+	for i, entry := range entries {
+		candidates[i] = resolve.Candidate{ID: entry.ID, Title: entry.Title}
+	}
+	return resolve.Resolve(ref, candidates)
+}
+
+func (s *Service) GetEntry(ref string) (models.JournalEntry, error) {
 	var entry models.JournalEntry
+	id, err := s.Resolve(ref)
+	if err != nil {
+		return entry, err
+	}
 	result := s.DB.First(&entry, "id = ?", id)
 	return entry, result.Error
 }
 
-func (s *Service) DeleteEntry(id string) error {
+func (s *Service) DeleteEntry(ref string) error {
+	id, err := s.Resolve(ref)
+	if err != nil {
+		return err
+	}
 	result := s.DB.Delete(&models.JournalEntry{}, "id = ?", id)
 	return result.Error
 }
@@ -0,0 +1,73 @@
+package resolve
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/snehmatic/mindloop/internal/utils"
+)
+
+var ErrNotFound = errors.New("no matching record found")
+
+// Candidate is a record that can be referred to by ID, slug or title
+type Candidate struct {
+	ID    uint
+	Title string
+}
+
+// AmbiguousError is returned when a reference matches more than one record
+type AmbiguousError struct {
+	Ref     string
+	Matches []Candidate
+}
+
+func (e *AmbiguousError) Error() string {
+	var options []string
+	for _, c := range e.Matches {
+		options = append(options, fmt.Sprintf("%d (%s)", c.ID, c.Title))
+	}
+	return fmt.Sprintf("%q is ambiguous, did you mean: %s", e.Ref, strings.Join(options, ", "))
+}
+
+// Resolve finds the candidate ref points at. In order of precedence ref can be
+// a numeric ID, an exact slug or title, or a prefix of exactly one title.
+func Resolve(ref string, candidates []Candidate) (uint, error) {
+	ref = strings.TrimSpace(ref)
+	if id, err := strconv.ParseUint(ref, 10, 0); err == nil {
+		for _, c := range candidates {
+			if c.ID == uint(id) {
+				return c.ID, nil
+			}
+		}
+	}
+
+	slug := utils.Slugify(ref)
+	if slug == "" {
+		return 0, fmt.Errorf("%w: %q", ErrNotFound, ref)
+	}
+
+	var exact, prefix []Candidate
+	for _, c := range candidates {
+		candidateSlug := utils.Slugify(c.Title)
+		switch {
+		case candidateSlug == slug:
+			exact = append(exact, c)
+		case strings.HasPrefix(candidateSlug, slug):
+			prefix = append(prefix, c)
+		}
+	}
+
+	for _, matches := range [][]Candidate{exact, prefix} {
+		switch len(matches) {
+		case 0:
+			continue
+		case 1:
+			return matches[0].ID, nil
+		default:
+			return 0, &AmbiguousError{Ref: ref, Matches: matches}
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrNotFound, ref)
+}
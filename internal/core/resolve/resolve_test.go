@@ -0,0 +1,45 @@
+package resolve
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	candidates := []Candidate{
+		{ID: 1, Title: "Gym"},
+		{ID: 2, Title: "Gym stretches"},
+		{ID: 3, Title: "Drink Water"},
+		{ID: 4, Title: "Read 10 pages"},
+		{ID: 5, Title: "Read news"},
+	}
+
+	cases := []struct {
+		ref  string
+		want uint
+	}{
+		{"3", 3},
+		{"gym", 1},         // exact slug wins over the "gym-stretches" prefix
+		{"gym-str", 2},     // unique prefix
+		{"drink-water", 3}, // exact slug
+		{"Drink water", 3}, // title, case insensitive
+		{"read 10", 4},     // unique prefix with spaces
+	}
+	for _, tc := range cases {
+		got, err := Resolve(tc.ref, candidates)
+		if err != nil || got != tc.want {
+			t.Errorf("Resolve(%q) = %d, %v; want %d", tc.ref, got, err, tc.want)
+		}
+	}
+
+	var ambiguous *AmbiguousError
+	if _, err := Resolve("read", candidates); !errors.As(err, &ambiguous) || len(ambiguous.Matches) != 2 {
+		t.Errorf("expected ambiguous error with 2 matches, got %v", err)
+	}
+	if _, err := Resolve("swim", candidates); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+	if _, err := Resolve("42", candidates); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected unknown ID to be not found, got %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package routine
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/snehmatic/mindloop/internal/core/focus"
+	"github.com/snehmatic/mindloop/internal/core/habit"
+	"github.com/snehmatic/mindloop/internal/core/resolve"
+	"github.com/snehmatic/mindloop/internal/utils"
+	"github.com/snehmatic/mindloop/models"
+	"gorm.io/gorm"
+)
+
+type Service struct {
+	DB     *gorm.DB
+	Habits *habit.Service
+	Focus  *focus.Service
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{DB: db, Habits: habit.NewService(db), Focus: focus.NewService(db)}
+}
+
+var (
+	ErrNoSteps     = errors.New("a routine needs at least one step")
+	ErrNameTaken   = errors.New("a routine with that name already exists")
+	ErrAvoidHabit  = errors.New("habits to avoid have nothing to log and can't be part of a routine")
+	ErrFocusFormat = errors.New(`invalid focus block, use e.g. "focus:25m" or "focus:Deep work:25m"`)
+)
+
+// ParseSteps turns step specs into routine steps. A spec is a habit ref,
+// see habit.Service.Resolve, or a focus block: "focus", "focus:25m",
+// "focus:Deep work" or "focus:Deep work:25m".
+func (s *Service) ParseSteps(specs []string) ([]models.RoutineStep, error) {
+	var steps []models.RoutineStep
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		step, err := s.parseStep(spec)
+		if err != nil {
+			return nil, fmt.Errorf("step %q: %w", spec, err)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+func (s *Service) parseStep(spec string) (models.RoutineStep, error) {
+	kind, rest, _ := strings.Cut(spec, ":")
+	if !strings.EqualFold(strings.TrimSpace(kind), "focus") {
+		h, err := s.Habits.GetHabit(spec)
+		if err != nil {
+			return models.RoutineStep{}, err
+		}
+		if h.IsAvoid() {
+			return models.RoutineStep{}, ErrAvoidHabit
+		}
+		return models.RoutineStep{HabitID: &h.ID, Habit: h}, nil
+	}
+
+	step := models.RoutineStep{FocusTitle: "Focus"}
+	parts := strings.Split(rest, ":")
+	if last := strings.TrimSpace(parts[len(parts)-1]); last != "" {
+		if d, err := time.ParseDuration(last); err == nil {
+			if d < time.Minute {
+				return step, ErrFocusFormat
+			}
+			step.FocusMinutes = int(d.Minutes())
+			parts = parts[:len(parts)-1]
+		}
+	}
+	if len(parts) > 1 {
+		return step, ErrFocusFormat
+	}
+	if len(parts) == 1 && strings.TrimSpace(parts[0]) != "" {
+		step.FocusTitle = strings.TrimSpace(parts[0])
+	}
+	if len(step.FocusTitle) > 100 {
+		return step, errors.New("focus block title cannot be longer than 100 characters")
+	}
+	return step, nil
+}
+
+// CreateRoutine saves the routine with its steps in the given order
+func (s *Service) CreateRoutine(r *models.Routine) error {
+	if r == nil {
+		return errors.New("routine cannot be nil")
+	}
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("routine name cannot be empty")
+	}
+	if len(r.Name) > 100 {
+		return errors.New("routine name cannot be longer than 100 characters")
+	}
+	if len(r.Steps) == 0 {
+		return ErrNoSteps
+	}
+
+	routines, err := s.ListRoutines()
+	if err != nil {
+		return err
+	}
+	for _, existing := range routines {
+		if utils.Slugify(existing.Name) == utils.Slugify(r.Name) {
+			return ErrNameTaken
+		}
+	}
+
+	for i := range r.Steps {
+		r.Steps[i].Position = i + 1
+		r.Steps[i].Habit = nil // only link it
+	}
+	return s.DB.Create(r).Error
+}
+
+// withSteps preloads the steps of routines in order, along with their habits
+// and the definitions their schedules depend on
+func withSteps(db *gorm.DB) *gorm.DB {
+	return db.Preload("Steps", func(db *gorm.DB) *gorm.DB {
+		return db.Order("Position")
+	}).Preload("Steps.Habit").Preload("Steps.Habit.Pauses").Preload("Steps.Habit.Revisions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("EffectiveFrom, id")
+	})
+}
+
+func (s *Service) ListRoutines() ([]models.Routine, error) {
+	var routines []models.Routine
+	err := withSteps(s.DB).Order("Name").Find(&routines).Error
+	return routines, err
+}
+
+// Resolve finds the ID of the routine ref points at, see resolve.Resolve
+func (s *Service) Resolve(ref string) (uint, error) {
+	var routines []models.Routine
+	if err := s.DB.Find(&routines).Error; err != nil {
+		return 0, err
+	}
+	candidates := make([]resolve.Candidate, len(routines))
+	for i, r := range routines {
+		candidates[i] = resolve.Candidate{ID: r.ID, Title: r.Name}
+	}
+	return resolve.Resolve(ref, candidates)
+}
+
+// GetRoutine loads the routine ref points at, by ID, slug or name prefix
+func (s *Service) GetRoutine(ref string) (*models.Routine, error) {
+	id, err := s.Resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+	var r models.Routine
+	if err := withSteps(s.DB).First(&r, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// DeleteRoutine deletes the routine and its steps. Past runs are kept for summaries.
+func (s *Service) DeleteRoutine(ref string) error {
+	r, err := s.GetRoutine(ref)
+	if err != nil {
+		return err
+	}
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("RoutineID = ?", r.ID).Delete(&models.RoutineStep{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(r).Error
+	})
+}
+
+// StepDue reports whether the step is due at now. Focus blocks always are,
+// habits are not while archived, paused, deleted or on their rest days.
+func (s *Service) StepDue(step models.RoutineStep, now time.Time) bool {
+	if step.IsFocus() {
+		return true
+	}
+	h := step.Habit
+	if h == nil || h.ID == 0 || h.IsArchived() {
+		return false
+	}
+	cal := s.Habits.Calendar
+	return habit.IsScheduled(cal, h, now) && h.PauseCovering(cal, cal.Day(now)) == nil
+}
+
+// StepDone reports whether the habit of the step already reached its
+// target in the current period, or the period was skipped. Focus blocks
+// are never done up front.
+func (s *Service) StepDone(step models.RoutineStep, now time.Time) (bool, error) {
+	if step.IsFocus() || step.Habit == nil || step.Habit.ID == 0 {
+		return false, nil
+	}
+	_, settled, err := s.Habits.Progress(step.Habit, now)
+	return settled, err
+}
+
+// LogStep logs the habit of the step for today via habit.Service.LogHabit
+func (s *Service) LogStep(step models.RoutineStep, opts habit.LogOptions) (*models.Habit, *models.HabitLog, error) {
+	if step.IsFocus() {
+		return nil, nil, errors.New("focus blocks are not logged, start a focus session instead")
+	}
+	return s.Habits.LogHabit(strconv.FormatUint(uint64(*step.HabitID), 10), opts)
+}
+
+// SaveRun records a finished run of the routine
+func (s *Service) SaveRun(r *models.Routine, done, total int) (*models.RoutineRun, error) {
+	run := &models.RoutineRun{
+		RoutineID:  r.ID,
+		Name:       r.Name,
+		StepsDone:  done,
+		StepsTotal: total,
+		EndedAt:    time.Now(),
+	}
+	if err := s.DB.Create(run).Error; err != nil {
+		return nil, err
+	}
+	return run, nil
+}
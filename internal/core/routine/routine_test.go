@@ -0,0 +1,121 @@
+package routine
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/snehmatic/mindloop/internal/core/focus"
+	"github.com/snehmatic/mindloop/internal/core/habit"
+	"github.com/snehmatic/mindloop/internal/period"
+	"github.com/snehmatic/mindloop/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func newTestService(t *testing.T) *Service {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true, NoLowerCase: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect to test db: %v", err)
+	}
+	err = db.AutoMigrate(&models.Habit{}, &models.HabitLog{}, &models.HabitPause{}, &models.HabitEvent{}, &models.HabitRevision{},
+		&models.FocusSession{}, &models.FocusSegment{}, &models.Routine{}, &models.RoutineStep{}, &models.RoutineRun{})
+	if err != nil {
+		t.Fatalf("Failed to migrate test db: %v", err)
+	}
+	habits := &habit.Service{DB: db, Calendar: period.New(time.UTC, time.Monday)}
+	return &Service{DB: db, Habits: habits, Focus: focus.NewService(db)}
+}
+
+func TestRoutine(t *testing.T) {
+	s := newTestService(t)
+	for _, h := range []*models.Habit{
+		{Title: "Meditate", TargetCount: 1, Interval: models.Daily},
+		{Title: "Stretch", TargetCount: 1, Interval: models.Daily},
+		{Title: "No sugar", Kind: models.KindAvoid, Interval: models.Daily},
+	} {
+		if err := s.Habits.CreateHabit(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := s.ParseSteps([]string{"no-sugar"}); !errors.Is(err, ErrAvoidHabit) {
+		t.Errorf("expected habits to avoid to be rejected, got %v", err)
+	}
+	if _, err := s.ParseSteps([]string{"focus:a:b:25m"}); !errors.Is(err, ErrFocusFormat) {
+		t.Errorf("expected a malformed focus block to be rejected, got %v", err)
+	}
+
+	steps, err := s.ParseSteps([]string{"meditate", "focus:Plan the day:15m", "stretch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateRoutine(&models.Routine{Name: "Morning", Steps: steps}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateRoutine(&models.Routine{Name: "morning ", Steps: steps}); !errors.Is(err, ErrNameTaken) {
+		t.Errorf("expected the name to be taken, got %v", err)
+	}
+
+	r, err := s.GetRoutine("morning")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := models.ToRoutineView(*r).Steps; got != "Meditate → focus: Plan the day (15m) → Stretch" {
+		t.Fatalf("unexpected steps %q", got)
+	}
+
+	now := time.Now()
+	meditate := r.Steps[0]
+	if done, err := s.StepDone(meditate, now); err != nil || done {
+		t.Fatalf("expected meditate not to be done yet, got %v (%v)", done, err)
+	}
+	if _, _, err := s.LogStep(meditate, habit.LogOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if done, err := s.StepDone(meditate, now); err != nil || !done {
+		t.Errorf("expected meditate to be done once logged, got %v (%v)", done, err)
+	}
+
+	// Skipped periods count as done, paused habits aren't due
+	if _, _, err := s.Habits.SkipHabit("stretch", time.Time{}, "sore"); err != nil {
+		t.Fatal(err)
+	}
+	if done, err := s.StepDone(r.Steps[2], now); err != nil || !done {
+		t.Errorf("expected a skipped stretch to be done, got %v (%v)", done, err)
+	}
+	if _, _, err := s.Habits.PauseHabit("stretch", time.Time{}, time.Time{}, "vacation"); err != nil {
+		t.Fatal(err)
+	}
+	r, _ = s.GetRoutine("morning")
+	if s.StepDue(r.Steps[2], now) {
+		t.Error("expected a paused habit not to be due")
+	}
+	if _, err := s.Habits.ResumeHabit("stretch"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Habits.ArchiveHabit("stretch"); err != nil {
+		t.Fatal(err)
+	}
+	r, _ = s.GetRoutine("morning")
+	if s.StepDue(r.Steps[2], now) || !s.StepDue(r.Steps[1], now) {
+		t.Error("expected archived habits not to be due and focus blocks to be")
+	}
+
+	run, err := s.SaveRun(r, 2, 2)
+	if err != nil || !run.Completed() {
+		t.Errorf("expected a completed run, got %+v (%v)", run, err)
+	}
+	if err := s.DeleteRoutine("morning"); err != nil {
+		t.Fatal(err)
+	}
+	var runs int64
+	s.DB.Model(&models.RoutineRun{}).Count(&runs)
+	if runs != 1 {
+		t.Errorf("expected past runs to be kept, got %d", runs)
+	}
+}
@@ -2,19 +2,24 @@ package summary
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
+	"github.com/snehmatic/mindloop/internal/config"
+	"github.com/snehmatic/mindloop/internal/core/habit"
 	"github.com/snehmatic/mindloop/internal/utils"
 	"github.com/snehmatic/mindloop/models"
 	"gorm.io/gorm"
 )
 
 type Service struct {
-	DB *gorm.DB
+	DB         *gorm.DB
+	IdleCutoff time.Duration // stale focus sessions count up to it only
+	habits     *habit.Service
 }
 
 func NewService(db *gorm.DB) *Service {
-	return &Service{DB: db}
+	return &Service{DB: db, IdleCutoff: config.GetConfig().FocusIdleCutoff(), habits: habit.NewService(db)}
 }
 
 func (s *Service) GenerateSummary(start, end time.Time) (models.SummaryReport, error) {
@@ -33,10 +38,17 @@ func (s *Service) GenerateSummary(start, end time.Time) (models.SummaryReport, e
 		return models.SummaryReport{}, err
 	}
 
+	routineStats, err := s.GetRoutineStats(start, end)
+	if err != nil {
+		return models.SummaryReport{}, err
+	}
+
 	return models.SummaryReport{
 		DateRange: fmt.Sprintf("%s to %s", start.Format("02-Jan-2006"), end.Format("02-Jan-2006")),
 		Focus:     focusStats,
 		Habits:    habitStats,
+		Tags:      GetTagStats(habitStats),
+		Routines:  routineStats,
 		Intents:   intentStats,
 	}, nil
 }
@@ -45,7 +57,7 @@ func (s *Service) GetFocusStats(start, end time.Time) (models.FocusStats, error)
 	var sessions []models.FocusSession
 	rangeQuery := "CreatedAt >= ? AND CreatedAt <= ?"
 
-	if err := s.DB.Where(rangeQuery, start, end).Find(&sessions).Error; err != nil {
+	if err := s.DB.Where(rangeQuery, start, end).Preload("Segments").Find(&sessions).Error; err != nil {
 		return models.FocusStats{}, err
 	}
 	if len(sessions) == 0 {
@@ -55,65 +67,251 @@ func (s *Service) GetFocusStats(start, end time.Time) (models.FocusStats, error)
 			LongestSession: "0 mins",
 		}, nil
 	}
+	// Pauses don't count, sessions still running count up to now and stale
+	// ones up to the idle cutoff
+	now := time.Now()
 	totalDuration := 0.0
 	longestSession := 0.0
+	pomodoros, planned, stale := 0, 0, 0
+	overrun, underrun := 0.0, 0.0
 	for _, session := range sessions {
-		totalDuration += session.Duration
-		if session.Duration > longestSession {
-			longestSession = session.Duration
+		pomodoros += session.Pomodoros()
+		if session.IsStale(now, s.IdleCutoff) {
+			stale++
+		}
+		minutes := session.FocusedMinutes(now, s.IdleCutoff)
+		totalDuration += minutes
+		if minutes > longestSession {
+			longestSession = minutes
+		}
+		// Only ended sessions are judged against their plan
+		if session.IsPlanned() && session.Status == "ended" {
+			planned++
+			if delta := session.PlanDelta(now); delta > 0 {
+				overrun += delta
+			} else {
+				underrun -= delta
+			}
 		}
 	}
 	return models.FocusStats{
-		TotalSessions:  len(sessions),
-		TotalDuration:  utils.FormatMinutes(totalDuration),
-		LongestSession: utils.FormatMinutes(longestSession),
+		TotalSessions:   len(sessions),
+		TotalDuration:   utils.FormatMinutes(totalDuration),
+		LongestSession:  utils.FormatMinutes(longestSession),
+		Pomodoros:       pomodoros,
+		PlannedSessions: planned,
+		Overrun:         utils.FormatMinutes(overrun),
+		Underrun:        utils.FormatMinutes(underrun),
+		StaleSessions:   stale,
 	}, nil
 }
 
 func (s *Service) GetHabitStats(start, end time.Time) ([]models.HabitStats, error) {
-	var habits []models.Habit
-	if err := s.DB.Find(&habits).Error; err != nil {
+	// Archived habits are included, their history still counts
+	habits, err := s.habits.ListHabits(habit.ListOptions{IncludeArchived: true})
+	if err != nil {
 		return nil, err
 	}
 	if len(habits) == 0 {
 		return []models.HabitStats{}, nil
 	}
 
+	// Logs are matched by the period they were logged for, not when they were
+	// written, so backfilled logs land in the right range
 	var habitLogs []models.HabitLog
-	rangeQuery := "CreatedAt >= ? AND CreatedAt <= ?"
-	if err := s.DB.Where(rangeQuery, start, end).Order("CreatedAt DESC").Find(&habitLogs).Error; err != nil {
+	rangeQuery := "EndedAt >= ? AND StartedAt <= ?"
+	if err := s.DB.Where(rangeQuery, s.habits.Calendar.StartOfDay(start), end).Order("EndedAt DESC").Find(&habitLogs).Error; err != nil {
 		return nil, err
 	}
 
-	if len(habitLogs) == 0 {
-		// Even if no logs, we should return stats for habits with 0 completion to show they exist
-		// But if no logs exist AT ALL for the period, logic still holds
-		// Better to just continue and calculate 0s
+	// Periods that have not started yet are not due
+	now := time.Now()
+	if end.After(now) {
+		end = now
 	}
 
-	totalCompletedLogsForHabit := 0
-	totalLogsForHabit := 0
-
 	var stats []models.HabitStats
-	for _, habit := range habits {
-		totalCompletedLogsForHabit = 0
-		totalLogsForHabit = 0
+	for _, h := range habits {
+		// Periods before the habit existed are not due either
+		from := start
+		if h.CreatedAt.After(from) {
+			from = h.CreatedAt
+		}
+		due := map[int64]bool{}
+		if !from.After(end) {
+			for _, p := range habit.Periods(s.habits.Calendar, &h, from, end) {
+				due[p.End.Unix()] = true
+			}
+		}
+		// The current period is only due once settled, until then it is
+		// neither done nor missed
+		if current := habit.PeriodFor(s.habits.Calendar, &h, now); due[current.End.Unix()] {
+			_, settled, err := s.habits.Progress(&h, now)
+			if err != nil {
+				return nil, err
+			}
+			if !settled {
+				delete(due, current.End.Unix())
+			}
+		}
+		periodsDue := len(due)
+
+		totalLogsForHabit := 0
+		totalCompletedLogsForHabit := 0
+		totalAmount := 0
+		relapsedPeriods := 0
+		skippedPeriods := 0
+		completedOnTime := 0
 		for _, log := range habitLogs {
-			if log.HabitID == habit.ID {
+			if log.HabitID == h.ID {
+				// Skipped periods are excused, they leave the denominator
+				if log.Skipped {
+					totalAmount += log.ActualCount
+					if due[log.EndedAt.Unix()] {
+						periodsDue--
+						skippedPeriods++
+					}
+					continue
+				}
 				totalLogsForHabit++
+				if h.IsAvoid() {
+					totalAmount += log.Relapses()
+				} else {
+					totalAmount += log.ActualCount
+				}
+				// Relapses while paused or off schedule have no clean period to spoil
+				if log.Relapses() > 0 && due[log.EndedAt.Unix()] {
+					relapsedPeriods++
+				}
 				if log.ActualCount >= log.TargetCount {
 					totalCompletedLogsForHabit++
+					if log.CompletedOnTime() {
+						completedOnTime++
+					}
 				}
 			}
 		}
-		if totalLogsForHabit > 0 {
-			stats = append(stats, models.HabitStats{
-				HabitName:      habit.Title,
-				CompletionRate: float64(totalCompletedLogsForHabit) * 100 / float64(totalLogsForHabit),
-				LogsTracked:    totalLogsForHabit,
-				LogsCompleted:  totalCompletedLogsForHabit,
-			})
+		if periodsDue == 0 && totalLogsForHabit == 0 && skippedPeriods == 0 {
+			continue
+		}
+		// Habits to avoid succeed in every period without a relapse
+		if h.IsAvoid() {
+			totalCompletedLogsForHabit = max(periodsDue-relapsedPeriods, 0)
+		}
+
+		// Streaks are reported as of today, regardless of the range
+		streak, err := s.habits.GetStreak(&h)
+		if err != nil {
+			return nil, err
+		}
+		lastCompleted := "-"
+		if !streak.LastCompleted.IsZero() {
+			lastCompleted = streak.LastCompleted.Format("02-Jan-2006")
+		}
+
+		// Logs outside the schedule (e.g. made before an interval change) still count
+		denominator := max(periodsDue, totalCompletedLogsForHabit)
+		completionRate := 0.0
+		if denominator > 0 { // e.g. only logged while paused
+			completionRate = float64(totalCompletedLogsForHabit) * 100 / float64(denominator)
 		}
+		hs := models.HabitStats{
+			HabitName:      h.Title,
+			Tags:           h.TagList(),
+			CompletionRate: completionRate,
+			LogsTracked:    totalLogsForHabit,
+			LogsCompleted:  totalCompletedLogsForHabit,
+			Archived:       h.IsArchived(),
+			Avoid:          h.IsAvoid(),
+			PeriodsDue:     periodsDue,
+			Skipped:        skippedPeriods,
+			Unit:           h.Unit,
+			TotalAmount:    totalAmount,
+			CurrentStreak:  streak.Current,
+			LongestStreak:  streak.Longest,
+			LastCompleted:  lastCompleted,
+
+			DaysSinceRelapse: streak.DaysSinceRelapse,
+		}
+		// Habits to avoid only count relapses in their window, nothing is late
+		if h.HasWindow() && !h.IsAvoid() {
+			hs.Window = h.WindowLabel()
+			hs.CompletedOnTime = completedOnTime
+			hs.CompletedLate = totalCompletedLogsForHabit - completedOnTime
+			if denominator > 0 {
+				hs.OnTimeRate = float64(hs.CompletedOnTime) * 100 / float64(denominator)
+				hs.LateRate = float64(hs.CompletedLate) * 100 / float64(denominator)
+			}
+		}
+		stats = append(stats, hs)
+	}
+	return stats, nil
+}
+
+// GetTagStats groups the habit stats by tag, in alphabetical order with
+// untagged habits last. It returns nil when no habit is tagged.
+func GetTagStats(habitStats []models.HabitStats) []models.TagStats {
+	byTag := map[string]*models.TagStats{}
+	var tags []string
+	add := func(tag string, hs models.HabitStats) {
+		ts, ok := byTag[tag]
+		if !ok {
+			ts = &models.TagStats{Tag: tag}
+			byTag[tag] = ts
+			tags = append(tags, tag)
+		}
+		ts.Habits++
+		// Same denominator as the per habit completion rate
+		ts.PeriodsDue += max(hs.PeriodsDue, hs.LogsCompleted)
+		ts.LogsCompleted += hs.LogsCompleted
+	}
+	for _, hs := range habitStats {
+		if len(hs.Tags) == 0 {
+			add("", hs)
+		}
+		for _, tag := range hs.Tags {
+			add(tag, hs)
+		}
+	}
+	if len(tags) == 0 || len(tags) == 1 && tags[0] == "" {
+		return nil
+	}
+
+	sort.Slice(tags, func(i, j int) bool { return models.TagLess(tags[i], tags[j]) })
+	stats := make([]models.TagStats, len(tags))
+	for i, tag := range tags {
+		ts := *byTag[tag]
+		if ts.PeriodsDue > 0 {
+			ts.CompletionRate = float64(ts.LogsCompleted) * 100 / float64(ts.PeriodsDue)
+		}
+		stats[i] = ts
+	}
+	return stats
+}
+
+// GetRoutineStats sums up the routine runs that ended in the range, by
+// routine name in alphabetical order
+func (s *Service) GetRoutineStats(start, end time.Time) ([]models.RoutineStats, error) {
+	var runs []models.RoutineRun
+	if err := s.DB.Where("EndedAt >= ? AND EndedAt <= ?", start, end).Order("Name").Find(&runs).Error; err != nil {
+		return nil, err
+	}
+
+	var stats []models.RoutineStats
+	for _, run := range runs {
+		if len(stats) == 0 || stats[len(stats)-1].Name != run.Name {
+			stats = append(stats, models.RoutineStats{Name: run.Name})
+		}
+		rs := &stats[len(stats)-1]
+		rs.Runs++
+		rs.StepsDone += run.StepsDone
+		rs.StepsTotal += run.StepsTotal
+		if run.Completed() {
+			rs.Completed++
+		}
+	}
+	for i := range stats {
+		stats[i].CompletionRate = float64(stats[i].Completed) * 100 / float64(stats[i].Runs)
 	}
 	return stats, nil
 }
@@ -121,7 +319,7 @@ func (s *Service) GetHabitStats(start, end time.Time) ([]models.HabitStats, erro
 func (s *Service) GetIntentStats(start, end time.Time) ([]models.IntentStats, error) {
 	var intents []models.Intent
 	rangeQuery := "CreatedAt >= ? AND CreatedAt <= ?"
-	if err := s.DB.Where(rangeQuery, start, end).Find(&intents).Error; err != nil {
+	if err := s.DB.Where(rangeQuery, start, end).Preload("FocusSessions.Segments").Find(&intents).Error; err != nil {
 		return nil, err
 	}
 
@@ -129,11 +327,13 @@ func (s *Service) GetIntentStats(start, end time.Time) ([]models.IntentStats, er
 		return []models.IntentStats{}, nil
 	}
 
+	now := time.Now()
 	var stats []models.IntentStats
 	for _, intent := range intents {
 		stats = append(stats, models.IntentStats{
 			IntentName: intent.Name,
 			Status:     intent.Status,
+			FocusTime:  intent.FocusTime(now, s.IdleCutoff),
 		})
 	}
 	return stats, nil
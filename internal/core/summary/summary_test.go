@@ -0,0 +1,566 @@
+package summary
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/snehmatic/mindloop/internal/core/habit"
+	"github.com/snehmatic/mindloop/internal/period"
+	"github.com/snehmatic/mindloop/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+var cal = period.New(time.UTC, time.Monday)
+
+func newTestService(t *testing.T) *Service {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true, NoLowerCase: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to connect to test db: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Habit{}, &models.HabitLog{}, &models.HabitPause{}, &models.HabitRevision{}, &models.RoutineRun{}); err != nil {
+		t.Fatalf("Failed to migrate test db: %v", err)
+	}
+	return &Service{DB: db, habits: &habit.Service{DB: db, Calendar: cal}}
+}
+
+func day(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+// logs returns a log for the period of each day, completed or not, written
+// on that day
+func logs(h *models.Habit, actual int, days ...string) []models.HabitLog {
+	var habitLogs []models.HabitLog
+	for _, d := range days {
+		p := habit.PeriodFor(cal, h, day(d))
+		habitLogs = append(habitLogs, models.HabitLog{
+			HabitID:     h.ID,
+			Title:       h.Title,
+			Interval:    h.Interval,
+			TargetCount: h.TargetCount,
+			ActualCount: actual,
+			StartedAt:   p.Start,
+			EndedAt:     p.End,
+			Model:       gorm.Model{CreatedAt: day(d).Add(12 * time.Hour)},
+		})
+	}
+	return habitLogs
+}
+
+func TestGetHabitStats(t *testing.T) {
+	// Monday to Sunday
+	start, end := day("2025-03-03"), day("2025-03-09").Add(24*time.Hour-time.Second)
+	before := day("2025-01-01")
+	paused, archived := day("2025-03-07"), day("2025-03-06").Add(12*time.Hour)
+	lastDay := end.Add(-time.Hour)
+
+	cases := []struct {
+		name      string
+		habit     models.Habit
+		logs      func(h *models.Habit) []models.HabitLog
+		due       int
+		skipped   int
+		tracked   int
+		completed int
+		rate      float64
+		amount    int
+		current   int
+		longest   int
+		last      string
+	}{
+		{
+			name:  "daily",
+			habit: models.Habit{Interval: models.Daily},
+			logs: func(h *models.Habit) []models.HabitLog {
+				return append(logs(h, 1, "2025-03-03", "2025-03-04", "2025-03-05", "2025-03-07", "2025-03-08"), logs(h, 0, "2025-03-09")...)
+			},
+			due: 7, tracked: 6, completed: 5, rate: 500.0 / 7, amount: 5, longest: 3, last: "08-Mar-2025",
+		},
+		{
+			name:  "weekdays",
+			habit: models.Habit{Interval: models.OnWeekdays, Weekdays: "mon,wed,fri"},
+			logs: func(h *models.Habit) []models.HabitLog {
+				return logs(h, 1, "2025-03-03", "2025-03-05")
+			},
+			due: 3, tracked: 2, completed: 2, rate: 200.0 / 3, amount: 2, longest: 2, last: "05-Mar-2025",
+		},
+		{
+			name:  "every 3 days",
+			habit: models.Habit{Interval: models.EveryNDays, EveryN: 3, Model: gorm.Model{CreatedAt: day("2025-03-01")}},
+			logs: func(h *models.Habit) []models.HabitLog {
+				return logs(h, 1, "2025-03-06")
+			},
+			due: 3, tracked: 1, completed: 1, rate: 100.0 / 3, amount: 1, longest: 1, last: "04-Mar-2025",
+		},
+		{
+			name:  "weekly",
+			habit: models.Habit{Interval: models.Weekly, TargetCount: 3},
+			logs: func(h *models.Habit) []models.HabitLog {
+				return logs(h, 3, "2025-03-05")
+			},
+			due: 1, tracked: 1, completed: 1, rate: 100, amount: 3, longest: 1, last: "03-Mar-2025",
+		},
+		{
+			name:  "monthly",
+			habit: models.Habit{Interval: models.Monthly},
+			logs: func(h *models.Habit) []models.HabitLog {
+				return logs(h, 1, "2025-03-08")
+			},
+			due: 1, tracked: 1, completed: 1, rate: 100, amount: 1, longest: 1, last: "01-Mar-2025",
+		},
+		{
+			name:  "created during the range",
+			habit: models.Habit{Interval: models.Daily, Model: gorm.Model{CreatedAt: day("2025-03-06").Add(12 * time.Hour)}},
+			logs: func(h *models.Habit) []models.HabitLog {
+				return logs(h, 1, "2025-03-06", "2025-03-07")
+			},
+			due: 4, tracked: 2, completed: 2, rate: 50, amount: 2, longest: 2, last: "07-Mar-2025",
+		},
+		{
+			name:  "measurable",
+			habit: models.Habit{Interval: models.Daily, TargetCount: 2000, Unit: "ml"},
+			logs: func(h *models.Habit) []models.HabitLog {
+				return append(logs(h, 2250, "2025-03-03"), logs(h, 1500, "2025-03-04")...)
+			},
+			due: 7, tracked: 2, completed: 1, rate: 100.0 / 7, amount: 3750, longest: 1, last: "03-Mar-2025",
+		},
+		{
+			name:  "backfilled",
+			habit: models.Habit{Interval: models.Daily},
+			logs: func(h *models.Habit) []models.HabitLog {
+				// Logs count for the day they were logged for, not the day
+				// they were written
+				habitLogs := logs(h, 1, "2025-03-05", "2025-03-10")
+				habitLogs[0].CreatedAt = day("2025-03-12")
+				habitLogs[1].CreatedAt = day("2025-03-09")
+				return habitLogs
+			},
+			due: 7, tracked: 1, completed: 1, rate: 100.0 / 7, amount: 1, longest: 1, last: "10-Mar-2025",
+		},
+		{
+			name: "paused",
+			habit: models.Habit{Interval: models.Daily, Pauses: []models.HabitPause{
+				{StartedAt: day("2025-03-05"), EndedAt: &paused, Reason: "vacation"},
+			}},
+			logs: func(h *models.Habit) []models.HabitLog {
+				return logs(h, 1, "2025-03-03", "2025-03-04", "2025-03-08")
+			},
+			due: 4, tracked: 3, completed: 3, rate: 75, amount: 3, longest: 3, last: "08-Mar-2025",
+		},
+		{
+			name:  "archived",
+			habit: models.Habit{Interval: models.Daily, ArchivedAt: &archived},
+			logs: func(h *models.Habit) []models.HabitLog {
+				return logs(h, 1, "2025-03-03", "2025-03-04")
+			},
+			due: 4, tracked: 2, completed: 2, rate: 50, amount: 2, longest: 2, last: "04-Mar-2025",
+		},
+		{
+			// Archived so its streak ends with the range
+			name:  "avoid",
+			habit: models.Habit{Interval: models.Daily, Kind: models.KindAvoid, ArchivedAt: &lastDay, Model: gorm.Model{CreatedAt: start}},
+			logs: func(h *models.Habit) []models.HabitLog {
+				return append(logs(h, 2, "2025-03-04"), logs(h, 1, "2025-03-06")...)
+			},
+			due: 7, tracked: 2, completed: 5, rate: 500.0 / 7, amount: 3, current: 3, longest: 3, last: "09-Mar-2025",
+		},
+		{
+			// The relapse on 03-05 falls in the pause and spoils no clean day
+			name: "avoid while paused",
+			habit: models.Habit{Interval: models.Daily, Kind: models.KindAvoid, ArchivedAt: &lastDay, Model: gorm.Model{CreatedAt: start}, Pauses: []models.HabitPause{
+				{StartedAt: day("2025-03-05"), EndedAt: &paused, Reason: "vacation"},
+			}},
+			logs: func(h *models.Habit) []models.HabitLog {
+				return append(logs(h, 1, "2025-03-04"), logs(h, 1, "2025-03-05")...)
+			},
+			due: 4, tracked: 2, completed: 3, rate: 75, amount: 2, current: 2, longest: 2, last: "09-Mar-2025",
+		},
+		{
+			// Only relapses in the window count, the first one is outside
+			name:  "avoid with a window",
+			habit: models.Habit{Interval: models.Daily, Kind: models.KindAvoid, WindowStart: "22:00", ArchivedAt: &lastDay, Model: gorm.Model{CreatedAt: start}},
+			logs: func(h *models.Habit) []models.HabitLog {
+				habitLogs := logs(h, 1, "2025-03-04", "2025-03-06")
+				habitLogs[0].LateCount = 1
+				return habitLogs
+			},
+			due: 7, tracked: 2, completed: 6, rate: 600.0 / 7, amount: 1, current: 3, longest: 3, last: "09-Mar-2025",
+		},
+		{
+			// Daily until Thursday, Mondays only since
+			name: "schedule changed",
+			habit: models.Habit{Interval: models.OnWeekdays, Weekdays: "mon", Revisions: []models.HabitRevision{
+				{EffectiveFrom: before, Interval: models.Daily, TargetCount: 1},
+				{EffectiveFrom: day("2025-03-06"), Interval: models.OnWeekdays, Weekdays: "mon", TargetCount: 1},
+			}},
+			logs: func(h *models.Habit) []models.HabitLog {
+				return logs(h, 1, "2025-03-03", "2025-03-04")
+			},
+			due: 3, tracked: 2, completed: 2, rate: 200.0 / 3, amount: 2, longest: 2, last: "04-Mar-2025",
+		},
+		{
+			name:  "skipped",
+			habit: models.Habit{Interval: models.Daily},
+			logs: func(h *models.Habit) []models.HabitLog {
+				skipped := logs(h, 0, "2025-03-05")
+				skipped[0].Skipped, skipped[0].SkipReason = true, "sick"
+				return append(logs(h, 1, "2025-03-03", "2025-03-04"), skipped...)
+			},
+			due: 6, skipped: 1, tracked: 2, completed: 2, rate: 200.0 / 6, amount: 2, longest: 2, last: "04-Mar-2025",
+		},
+		{
+			name:  "logged outside the schedule",
+			habit: models.Habit{Interval: models.OnWeekdays, Weekdays: "mon"},
+			logs: func(h *models.Habit) []models.HabitLog {
+				return logs(h, 1, "2025-03-03", "2025-03-04", "2025-03-05")
+			},
+			due: 1, tracked: 3, completed: 3, rate: 100, amount: 3, longest: 1, last: "03-Mar-2025",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := newTestService(t)
+			h := c.habit
+			h.Title = "Habit"
+			if h.TargetCount == 0 && !h.IsAvoid() {
+				h.TargetCount = 1
+			}
+			if h.CreatedAt.IsZero() {
+				h.CreatedAt = before
+			}
+			if err := s.DB.Create(&h).Error; err != nil {
+				t.Fatal(err)
+			}
+			if habitLogs := c.logs(&h); len(habitLogs) > 0 {
+				if err := s.DB.Create(&habitLogs).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			stats, err := s.GetHabitStats(start, end)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stats) != 1 {
+				t.Fatalf("expected stats for one habit, got %+v", stats)
+			}
+			hs := stats[0]
+			if hs.PeriodsDue != c.due || hs.Skipped != c.skipped || hs.LogsTracked != c.tracked || hs.LogsCompleted != c.completed || hs.CompletionRate != c.rate {
+				t.Errorf("expected %d due, %d skipped, %d tracked, %d completed at %.1f%%, got %d, %d, %d, %d at %.1f%%",
+					c.due, c.skipped, c.tracked, c.completed, c.rate, hs.PeriodsDue, hs.Skipped, hs.LogsTracked, hs.LogsCompleted, hs.CompletionRate)
+			}
+			if hs.Archived != h.IsArchived() {
+				t.Errorf("expected archived to be %v", h.IsArchived())
+			}
+			if hs.TotalAmount != c.amount || hs.Unit != h.Unit {
+				t.Errorf("expected a total of %d %s, got %d %s", c.amount, h.Unit, hs.TotalAmount, hs.Unit)
+			}
+			// Streaks are as of today, long after the range for most habits
+			if hs.CurrentStreak != c.current || hs.LongestStreak != c.longest || hs.LastCompleted != c.last {
+				t.Errorf("expected streaks of %d and %d last completed %s, got %d and %d last completed %s",
+					c.current, c.longest, c.last, hs.CurrentStreak, hs.LongestStreak, hs.LastCompleted)
+			}
+		})
+	}
+}
+
+func TestGetHabitStatsWindow(t *testing.T) {
+	start, end := day("2025-03-03"), day("2025-03-09").Add(24*time.Hour-time.Second)
+
+	cases := []struct {
+		name          string
+		habit         models.Habit
+		logged        [][2]int // amount and late part of each day's log
+		window        string
+		onTime, lates int
+		onTimeRate    float64
+		lateRate      float64
+	}{
+		{
+			name:   "no window",
+			habit:  models.Habit{Interval: models.Daily, TargetCount: 1},
+			logged: [][2]int{{1, 0}, {1, 1}},
+		},
+		{
+			name:   "on time and late",
+			habit:  models.Habit{Interval: models.Daily, TargetCount: 1, WindowStart: "06:00", WindowEnd: "09:00"},
+			logged: [][2]int{{1, 0}, {1, 0}, {1, 1}, {1, 0}},
+			window: "06:00-09:00", onTime: 3, lates: 1, onTimeRate: 300.0 / 7, lateRate: 100.0 / 7,
+		},
+		{
+			// Relapses outside the window don't count, none are late
+			name:   "avoid",
+			habit:  models.Habit{Interval: models.Daily, Kind: models.KindAvoid, WindowStart: "22:00"},
+			logged: [][2]int{{1, 1}, {1, 0}},
+		},
+		{
+			// Partly late is on time as long as the rest reaches the target
+			name:   "partly late",
+			habit:  models.Habit{Interval: models.Daily, TargetCount: 2000, Unit: "ml", WindowEnd: "12:00"},
+			logged: [][2]int{{2500, 500}, {2000, 500}, {1000, 0}},
+			window: "before 12:00", onTime: 1, lates: 1, onTimeRate: 100.0 / 7, lateRate: 100.0 / 7,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := newTestService(t)
+			h := c.habit
+			h.Title, h.CreatedAt = "Habit", day("2025-01-01")
+			if err := s.DB.Create(&h).Error; err != nil {
+				t.Fatal(err)
+			}
+			for i, logged := range c.logged {
+				habitLog := logs(&h, logged[0], start.AddDate(0, 0, i).Format("2006-01-02"))[0]
+				habitLog.LateCount = logged[1]
+				if err := s.DB.Create(&habitLog).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			stats, err := s.GetHabitStats(start, end)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stats) != 1 {
+				t.Fatalf("expected stats for one habit, got %+v", stats)
+			}
+			hs := stats[0]
+			if hs.Window != c.window || hs.CompletedOnTime != c.onTime || hs.CompletedLate != c.lates || hs.OnTimeRate != c.onTimeRate || hs.LateRate != c.lateRate {
+				t.Errorf("expected %q with %d on time at %.1f%% and %d late at %.1f%%, got %q with %d at %.1f%% and %d at %.1f%%",
+					c.window, c.onTime, c.onTimeRate, c.lates, c.lateRate, hs.Window, hs.CompletedOnTime, hs.OnTimeRate, hs.CompletedLate, hs.LateRate)
+			}
+		})
+	}
+}
+
+func TestGetHabitStatsOpenPeriod(t *testing.T) {
+	// The last three days, today's period is still open
+	today := cal.StartOfDay(time.Now())
+	start, end := cal.AddDays(today, -2), today.Add(24*time.Hour-time.Second)
+	ymd := today.Format("2006-01-02")
+
+	cases := []struct {
+		name      string
+		habit     models.Habit
+		logs      func(h *models.Habit) []models.HabitLog
+		due       int // -1 for no stats at all
+		skipped   int
+		completed int
+	}{
+		{
+			name:  "not done yet",
+			habit: models.Habit{Interval: models.Daily},
+			due:   2,
+		},
+		{
+			name:  "done today",
+			habit: models.Habit{Interval: models.Daily},
+			logs: func(h *models.Habit) []models.HabitLog {
+				return logs(h, 1, ymd)
+			},
+			due: 3, completed: 1,
+		},
+		{
+			name:  "skipped today",
+			habit: models.Habit{Interval: models.Daily},
+			logs: func(h *models.Habit) []models.HabitLog {
+				skipped := logs(h, 0, ymd)
+				skipped[0].Skipped = true
+				return skipped
+			},
+			due: 2, skipped: 1,
+		},
+		{
+			name:  "created today",
+			habit: models.Habit{Interval: models.EveryNDays, EveryN: 3, Model: gorm.Model{CreatedAt: today}},
+			due:   -1,
+		},
+		{
+			name:  "avoid, clean so far",
+			habit: models.Habit{Interval: models.Daily, Kind: models.KindAvoid},
+			due:   2, completed: 2,
+		},
+		{
+			name:  "avoid, relapsed today",
+			habit: models.Habit{Interval: models.Daily, Kind: models.KindAvoid},
+			logs: func(h *models.Habit) []models.HabitLog {
+				return logs(h, 1, ymd)
+			},
+			due: 3, completed: 2,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := newTestService(t)
+			h := c.habit
+			h.Title = "Habit"
+			if !h.IsAvoid() {
+				h.TargetCount = 1
+			}
+			if h.CreatedAt.IsZero() {
+				h.CreatedAt = start
+			}
+			if err := s.DB.Create(&h).Error; err != nil {
+				t.Fatal(err)
+			}
+			if c.logs != nil {
+				habitLogs := c.logs(&h)
+				if err := s.DB.Create(&habitLogs).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			stats, err := s.GetHabitStats(start, end)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.due < 0 {
+				if len(stats) != 0 {
+					t.Errorf("expected no stats before the first period is over, got %+v", stats)
+				}
+				return
+			}
+			if len(stats) != 1 {
+				t.Fatalf("expected stats for one habit, got %+v", stats)
+			}
+			if hs := stats[0]; hs.PeriodsDue != c.due || hs.Skipped != c.skipped || hs.LogsCompleted != c.completed {
+				t.Errorf("expected %d due, %d skipped and %d completed, got %d, %d and %d",
+					c.due, c.skipped, c.completed, hs.PeriodsDue, hs.Skipped, hs.LogsCompleted)
+			}
+		})
+	}
+}
+
+func TestGetHabitStatsNotDue(t *testing.T) {
+	s := newTestService(t)
+	h := models.Habit{Title: "Later", TargetCount: 1, Interval: models.Daily, Model: gorm.Model{CreatedAt: day("2025-04-01")}}
+	if err := s.DB.Create(&h).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Habits created after the range have nothing due in it
+	stats, err := s.GetHabitStats(day("2025-03-03"), day("2025-03-09"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 0 {
+		t.Errorf("expected no stats for a habit created after the range, got %+v", stats)
+	}
+}
+
+func TestGetHabitStatsCalendar(t *testing.T) {
+	s := newTestService(t)
+	s.habits.Calendar = period.New(time.UTC, time.Sunday)
+	h := models.Habit{Title: "Gym", TargetCount: 1, Interval: models.Weekly, Model: gorm.Model{CreatedAt: day("2025-01-01")}}
+	if err := s.DB.Create(&h).Error; err != nil {
+		t.Fatal(err)
+	}
+	p := habit.PeriodFor(s.habits.Calendar, &h, day("2025-03-08"))
+	habitLog := models.HabitLog{HabitID: h.ID, Title: h.Title, Interval: h.Interval, TargetCount: 1, ActualCount: 1, StartedAt: p.Start, EndedAt: p.End}
+	if err := s.DB.Create(&habitLog).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// With weeks starting on Sunday, Monday to Sunday spans two weeks
+	stats, err := s.GetHabitStats(day("2025-03-03"), day("2025-03-09"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 || stats[0].PeriodsDue != 2 || stats[0].LogsCompleted != 1 {
+		t.Errorf("expected one of two weeks completed, got %+v", stats)
+	}
+}
+
+func TestGetTagStats(t *testing.T) {
+	cases := []struct {
+		name   string
+		habits []models.HabitStats
+		want   []models.TagStats
+	}{
+		{name: "no habits"},
+		{
+			name:   "nothing tagged",
+			habits: []models.HabitStats{{HabitName: "Read", PeriodsDue: 7, LogsCompleted: 7}},
+		},
+		{
+			name: "grouped",
+			habits: []models.HabitStats{
+				{HabitName: "Stretch", PeriodsDue: 7},
+				{HabitName: "Gym", Tags: []string{"health", "work"}, PeriodsDue: 7, LogsCompleted: 7},
+				{HabitName: "Read", Tags: []string{"learning"}, PeriodsDue: 7, LogsCompleted: 3},
+				// Logged outside its schedule, completed logs are the denominator
+				{HabitName: "Walk", Tags: []string{"health"}, PeriodsDue: 4, LogsCompleted: 6},
+			},
+			want: []models.TagStats{
+				{Tag: "health", Habits: 2, PeriodsDue: 13, LogsCompleted: 13, CompletionRate: 100},
+				{Tag: "learning", Habits: 1, PeriodsDue: 7, LogsCompleted: 3, CompletionRate: 300.0 / 7},
+				{Tag: "work", Habits: 1, PeriodsDue: 7, LogsCompleted: 7, CompletionRate: 100},
+				{Tag: "", Habits: 1, PeriodsDue: 7},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := GetTagStats(c.habits); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("expected %+v, got %+v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestGetRoutineStats(t *testing.T) {
+	start, end := day("2025-03-03"), day("2025-03-09").Add(24*time.Hour-time.Second)
+	run := func(name string, done, total int, ended string) models.RoutineRun {
+		return models.RoutineRun{Name: name, StepsDone: done, StepsTotal: total, EndedAt: day(ended).Add(8 * time.Hour)}
+	}
+
+	cases := []struct {
+		name string
+		runs []models.RoutineRun
+		want []models.RoutineStats
+	}{
+		{name: "no runs"},
+		{
+			name: "grouped by name",
+			runs: []models.RoutineRun{
+				run("Morning", 3, 3, "2025-03-03"),
+				run("Morning", 2, 3, "2025-03-04"),
+				run("Evening", 2, 2, "2025-03-05"),
+				run("Morning", 3, 3, "2025-03-10"), // after the range
+			},
+			want: []models.RoutineStats{
+				{Name: "Evening", Runs: 1, Completed: 1, CompletionRate: 100, StepsDone: 2, StepsTotal: 2},
+				{Name: "Morning", Runs: 2, Completed: 1, CompletionRate: 50, StepsDone: 5, StepsTotal: 6},
+			},
+		},
+		{
+			// e.g. every habit of the routine was paused
+			name: "nothing due",
+			runs: []models.RoutineRun{run("Morning", 0, 0, "2025-03-03")},
+			want: []models.RoutineStats{{Name: "Morning", Runs: 1, Completed: 1, CompletionRate: 100}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := newTestService(t)
+			if len(c.runs) > 0 {
+				if err := s.DB.Create(&c.runs).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+			stats, err := s.GetRoutineStats(start, end)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(stats, c.want) {
+				t.Errorf("expected %+v, got %+v", c.want, stats)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/snehmatic/mindloop/internal/config"
+)
+
+// Notification is a single reminder to deliver
+type Notification struct {
+	HabitID uint      `json:"habit_id"`
+	Title   string    `json:"title"`   // e.g. "Drink water"
+	Message string    `json:"message"` // e.g. "500 ml of 2000 ml done today"
+	Due     time.Time `json:"due"`     // when the reminder was scheduled for
+}
+
+// Notifier delivers notifications, e.g. by printing them or calling a webhook
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// Stdout prints notifications to Out, os.Stdout if nil
+type Stdout struct {
+	Out io.Writer
+}
+
+func (s Stdout) Notify(_ context.Context, n Notification) error {
+	out := s.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	_, err := fmt.Fprintf(out, "🔔 %s %s: %s\n", n.Due.Format("15:04"), n.Title, n.Message)
+	return err
+}
+
+// Command runs a shell command per notification. The notification is
+// passed in the MINDLOOP_HABIT_ID, MINDLOOP_TITLE and MINDLOOP_MESSAGE
+// environment variables, e.g. `notify-send "$MINDLOOP_TITLE" "$MINDLOOP_MESSAGE"`.
+type Command struct {
+	Command string
+}
+
+func (c Command) Notify(ctx context.Context, n Notification) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.Command)
+	cmd.Env = append(os.Environ(),
+		"MINDLOOP_HABIT_ID="+strconv.FormatUint(uint64(n.HabitID), 10),
+		"MINDLOOP_TITLE="+n.Title,
+		"MINDLOOP_MESSAGE="+n.Message,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("reminder command failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Webhook posts notifications as JSON to URL
+type Webhook struct {
+	URL    string
+	Client *http.Client // http.DefaultClient if nil
+}
+
+func (wh Webhook) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := wh.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("reminder webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// FromConfig returns the notifier the reminder config asks for
+func FromConfig(rc config.ReminderConfig) (Notifier, error) {
+	switch rc.Notifier {
+	case "", "stdout":
+		return Stdout{}, nil
+	case "command":
+		if rc.Command == "" {
+			return nil, fmt.Errorf("the command notifier needs a command")
+		}
+		return Command{Command: rc.Command}, nil
+	case "webhook":
+		if rc.WebhookURL == "" {
+			return nil, fmt.Errorf("the webhook notifier needs a webhook_url")
+		}
+		return Webhook{URL: rc.WebhookURL, Client: &http.Client{Timeout: 10 * time.Second}}, nil
+	}
+	return nil, fmt.Errorf("unknown notifier %q, use stdout, command or webhook", rc.Notifier)
+}
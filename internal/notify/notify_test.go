@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/snehmatic/mindloop/internal/config"
+)
+
+var reminder = Notification{
+	HabitID: 7,
+	Title:   "Drink water",
+	Message: "500 ml of 2000 ml done today",
+	Due:     time.Date(2026, 10, 16, 7, 30, 0, 0, time.UTC),
+}
+
+func TestStdout(t *testing.T) {
+	var out bytes.Buffer
+	if err := (Stdout{Out: &out}).Notify(context.Background(), reminder); err != nil {
+		t.Fatal(err)
+	}
+	want := "🔔 07:30 Drink water: 500 ml of 2000 ml done today\n"
+	if out.String() != want {
+		t.Errorf("expected %q, got %q", want, out.String())
+	}
+}
+
+func TestCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out")
+	c := Command{Command: `printf '%s|%s|%s' "$MINDLOOP_HABIT_ID" "$MINDLOOP_TITLE" "$MINDLOOP_MESSAGE" > ` + path}
+	if err := c.Notify(context.Background(), reminder); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "7|Drink water|500 ml of 2000 ml done today"; string(got) != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	// A non-zero exit is reported along with what the command printed
+	err = Command{Command: "echo no display >&2; exit 3"}.Notify(context.Background(), reminder)
+	if err == nil || !strings.Contains(err.Error(), "no display") {
+		t.Errorf("expected the failed command to be reported, got %v", err)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	for _, rc := range []config.ReminderConfig{{}, {Notifier: "stdout"}} {
+		if n, err := FromConfig(rc); err != nil || n != (Stdout{}) {
+			t.Errorf("expected stdout for %+v, got %v, %v", rc, n, err)
+		}
+	}
+	if n, err := FromConfig(config.ReminderConfig{Notifier: "command", Command: "true"}); err != nil || n != (Command{Command: "true"}) {
+		t.Errorf("expected the command notifier, got %v, %v", n, err)
+	}
+	n, err := FromConfig(config.ReminderConfig{Notifier: "webhook", WebhookURL: "http://localhost:9000"})
+	if wh, ok := n.(Webhook); err != nil || !ok || wh.URL != "http://localhost:9000" {
+		t.Errorf("expected the webhook notifier, got %v, %v", n, err)
+	}
+
+	for _, rc := range []config.ReminderConfig{
+		{Notifier: "command"},
+		{Notifier: "webhook"},
+		{Notifier: "pigeon"},
+	} {
+		if _, err := FromConfig(rc); err == nil {
+			t.Errorf("expected an error for %+v", rc)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package period
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Period is a span of whole days. Start is midnight of the first day and
+// End is midnight of the last day, both in the calendar's timezone.
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Until returns the instant the period is over, i.e. midnight after End.
+func (p Period) Until() time.Time {
+	return time.Date(p.End.Year(), p.End.Month(), p.End.Day()+1, 0, 0, 0, 0, p.End.Location())
+}
+
+// Contains reports whether t falls within the period.
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.Until())
+}
+
+// Calendar computes day, week and month boundaries in the user's timezone.
+// All boundaries are calendar based, so days are 23 or 25 hours long
+// around DST changes instead of being truncated in UTC.
+type Calendar struct {
+	Location  *time.Location
+	WeekStart time.Weekday
+}
+
+// New returns a calendar for the given timezone and first day of the week.
+// A nil location means time.Local.
+func New(loc *time.Location, weekStart time.Weekday) Calendar {
+	if loc == nil {
+		loc = time.Local
+	}
+	return Calendar{Location: loc, WeekStart: weekStart}
+}
+
+// Default returns a calendar in the local timezone with weeks starting on Monday.
+func Default() Calendar {
+	return New(time.Local, time.Monday)
+}
+
+// StartOfDay returns midnight of the day containing t.
+func (c Calendar) StartOfDay(t time.Time) time.Time {
+	t = t.In(c.Location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.Location)
+}
+
+// AddDays moves t by n calendar days and returns midnight of that day.
+func (c Calendar) AddDays(t time.Time, n int) time.Time {
+	t = t.In(c.Location)
+	return time.Date(t.Year(), t.Month(), t.Day()+n, 0, 0, 0, 0, c.Location)
+}
+
+// DaysBetween returns the number of calendar days from a to b, negative if b is before a.
+func (c Calendar) DaysBetween(a, b time.Time) int {
+	a, b = a.In(c.Location), b.In(c.Location)
+	ua := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	ub := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(ub.Sub(ua).Hours() / 24)
+}
+
+// Days returns the span of n days starting on the day containing t.
+func (c Calendar) Days(t time.Time, n int) Period {
+	start := c.StartOfDay(t)
+	return Period{Start: start, End: c.AddDays(start, n-1)}
+}
+
+// Day returns the day containing t.
+func (c Calendar) Day(t time.Time) Period {
+	return c.Days(t, 1)
+}
+
+// Week returns the week containing t, starting on the calendar's WeekStart.
+func (c Calendar) Week(t time.Time) Period {
+	day := c.StartOfDay(t)
+	offset := (int(day.Weekday()) - int(c.WeekStart) + 7) % 7
+	return c.Days(c.AddDays(day, -offset), 7)
+}
+
+// Month returns the calendar month containing t.
+func (c Calendar) Month(t time.Time) Period {
+	t = t.In(c.Location)
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, c.Location)
+	return Period{Start: start, End: time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, c.Location)}
+}
+
+// ParseWeekday parses a weekday name, either abbreviated ("mon") or in full ("monday").
+func ParseWeekday(s string) (time.Weekday, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if s == name || s == name[:3] {
+			return d, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("invalid weekday: %s", s)
+}
@@ -0,0 +1,45 @@
+package period
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalendarBoundaries(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	// 21:30 on Saturday in New York is already Sunday in UTC
+	at := time.Date(2026, 10, 17, 21, 30, 0, 0, ny)
+
+	cases := []struct {
+		name       string
+		got        Period
+		start, end string
+	}{
+		{"day", New(ny, time.Monday).Day(at), "2026-10-17", "2026-10-17"},
+		{"week from monday", New(ny, time.Monday).Week(at), "2026-10-12", "2026-10-18"},
+		{"week from sunday", New(ny, time.Sunday).Week(at), "2026-10-11", "2026-10-17"},
+		{"month", New(ny, time.Monday).Month(at), "2026-10-01", "2026-10-31"},
+		{"utc day", New(time.UTC, time.Monday).Day(at), "2026-10-18", "2026-10-18"},
+	}
+	for _, tc := range cases {
+		if got := tc.got.Start.Format("2006-01-02"); got != tc.start {
+			t.Errorf("%s: expected start %s, got %s", tc.name, tc.start, got)
+		}
+		if got := tc.got.End.Format("2006-01-02"); got != tc.end {
+			t.Errorf("%s: expected end %s, got %s", tc.name, tc.end, got)
+		}
+	}
+
+	// The day DST ends is 25 hours long
+	dstEnd := New(ny, time.Monday).Day(time.Date(2026, 11, 1, 12, 0, 0, 0, ny))
+	if hours := dstEnd.Until().Sub(dstEnd.Start).Hours(); hours != 25 {
+		t.Errorf("expected 25 hour day, got %v", hours)
+	}
+	if days := New(ny, time.Monday).DaysBetween(dstEnd.Start, dstEnd.Until().Add(3*time.Hour)); days != 1 {
+		t.Errorf("expected 1 day between, got %d", days)
+	}
+}
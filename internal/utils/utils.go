@@ -10,6 +10,7 @@ import (
 
 	"reflect"
 	"strings"
+	"unicode"
 
 	"github.com/snehmatic/mindloop/internal/log"
 
@@ -303,3 +304,21 @@ func FormatMinutes(minutes float64) string {
 		return fmt.Sprintf("%dmin", mins)
 	}
 }
+
+// Slugify turns a title into a lowercase, dash separated slug, e.g. "Drink Water!" becomes "drink-water"
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
@@ -3,9 +3,13 @@ package models
 import (
 	"fmt"
 	"math"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/snehmatic/mindloop/internal/config"
+	"github.com/snehmatic/mindloop/internal/period"
+	"github.com/snehmatic/mindloop/internal/utils"
 	"gorm.io/gorm"
 )
 
@@ -14,27 +18,327 @@ import (
 
 type IntervalType string
 
-var AllIntervalTypes = [...]string{"daily", "weekly"}
+var AllIntervalTypes = [...]string{"daily", "weekly", "monthly", "every_n_days", "weekdays"}
 
 var (
-	Daily  IntervalType = IntervalType(AllIntervalTypes[0])
-	Weekly IntervalType = IntervalType(AllIntervalTypes[1])
+	Daily      IntervalType = IntervalType(AllIntervalTypes[0])
+	Weekly     IntervalType = IntervalType(AllIntervalTypes[1])
+	Monthly    IntervalType = IntervalType(AllIntervalTypes[2])
+	EveryNDays IntervalType = IntervalType(AllIntervalTypes[3])
+	OnWeekdays IntervalType = IntervalType(AllIntervalTypes[4])
+)
+
+// HabitKind tells habits to build ("read 10 pages") from habits to avoid ("no sugar")
+type HabitKind string
+
+var (
+	KindBuild HabitKind = "build"
+	KindAvoid HabitKind = "avoid" // successful unless a relapse is logged
 )
 
 type Habit struct {
 	gorm.Model
 	Title       string       `gorm:"type:varchar(100)" json:"title"`
 	Description string       `gorm:"type:text" json:"description"`
+	Kind        HabitKind    `gorm:"type:varchar(20);default:build" json:"kind"`
 	Interval    IntervalType `gorm:"type:varchar(100)" json:"interval"`
 	TargetCount int          `gorm:"type:int" json:"target_count"`
+	EveryN      int          `gorm:"type:int" json:"every_n"`           // every_n_days: period length in days
+	Weekdays    string       `gorm:"type:varchar(100)" json:"weekdays"` // weekdays: e.g. "mon,wed,fri"
+	Unit        string       `gorm:"type:varchar(50)" json:"unit"`      // measurable habits only, e.g. "ml" or "pages"
+	ArchivedAt  *time.Time   `json:"archived_at"`                       // archived habits are hidden from lists but kept in history
+	Tags        string       `gorm:"type:varchar(255)" json:"tags"`     // e.g. "health,learning"
+	RemindAt    string       `gorm:"type:varchar(5)" json:"remind_at"`  // time of day to remind at, e.g. "13:00", empty for none
+	RemindedOn  *time.Time   `json:"reminded_on"`                       // midnight of the day the last reminder went out
+	// Time of day window the habit should be done in, e.g. from "06:00" to
+	// "09:00". Either end may be empty, both for no window.
+	WindowStart string          `gorm:"type:varchar(5)" json:"window_start"`
+	WindowEnd   string          `gorm:"type:varchar(5)" json:"window_end"`
+	Managed     bool            `json:"managed"`  // defined in the habit manifest, kept in line by habit sync
+	Position    int             `json:"position"` // sort position in lists, pinned habits come first
+	Pinned      bool            `json:"pinned"`
+	Pauses      []HabitPause    `json:"pauses,omitempty"`
+	Revisions   []HabitRevision `json:"revisions,omitempty"` // ordered by EffectiveFrom
+}
+
+// HabitRevision is the definition of a habit from EffectiveFrom on, until
+// the next revision. Past periods are judged against the revision that was
+// in effect then, so editing a habit doesn't rewrite its history.
+type HabitRevision struct {
+	gorm.Model
+	HabitID       uint         `gorm:"not null;index" json:"habit_id"`
+	EffectiveFrom time.Time    `gorm:"not null" json:"effective_from"` // midnight of the first day
+	Title         string       `gorm:"type:varchar(100)" json:"title"`
+	Description   string       `gorm:"type:text" json:"description"`
+	Kind          HabitKind    `gorm:"type:varchar(20)" json:"kind"`
+	Interval      IntervalType `gorm:"type:varchar(100)" json:"interval"`
+	TargetCount   int          `gorm:"type:int" json:"target_count"`
+	EveryN        int          `gorm:"type:int" json:"every_n"`
+	Weekdays      string       `gorm:"type:varchar(100)" json:"weekdays"`
+	Unit          string       `gorm:"type:varchar(50)" json:"unit"`
+}
+
+// NewRevision captures the current definition of the habit
+func NewRevision(h Habit, effectiveFrom time.Time) HabitRevision {
+	return HabitRevision{
+		HabitID:       h.ID,
+		EffectiveFrom: effectiveFrom,
+		Title:         h.Title,
+		Description:   h.Description,
+		Kind:          h.Kind,
+		Interval:      h.Interval,
+		TargetCount:   h.TargetCount,
+		EveryN:        h.EveryN,
+		Weekdays:      h.Weekdays,
+		Unit:          h.Unit,
+	}
+}
+
+// Apply returns the habit with the definition of the revision
+func (r HabitRevision) Apply(h Habit) Habit {
+	h.Title = r.Title
+	h.Description = r.Description
+	h.Kind = r.Kind
+	h.Interval = r.Interval
+	h.TargetCount = r.TargetCount
+	h.EveryN = r.EveryN
+	h.Weekdays = r.Weekdays
+	h.Unit = r.Unit
+	return h
+}
+
+// SameSchedule reports whether both revisions split time into the same periods
+func (r HabitRevision) SameSchedule(o HabitRevision) bool {
+	return r.Interval == o.Interval && r.EveryN == o.EveryN && r.Weekdays == o.Weekdays
+}
+
+// RevisionAt returns the index of the revision in effect at t, -1 if t is
+// before the first revision or the habit has none.
+func (h Habit) RevisionAt(t time.Time) int {
+	at := -1
+	for i, r := range h.Revisions {
+		if r.EffectiveFrom.After(t) {
+			break
+		}
+		at = i
+	}
+	return at
+}
+
+// AsOf returns the habit as it was defined at t. Habits without revisions
+// are returned as is.
+func (h Habit) AsOf(t time.Time) Habit {
+	if i := h.RevisionAt(t); i >= 0 {
+		return h.Revisions[i].Apply(h)
+	}
+	if len(h.Revisions) > 0 { // before the first revision
+		return h.Revisions[0].Apply(h)
+	}
+	return h
+}
+
+// TagList returns the tags of the habit, e.g. ["health", "learning"]
+func (h Habit) TagList() []string {
+	return ParseTags(h.Tags)
+}
+
+// HasTag reports whether the habit is tagged with tag
+func (h Habit) HasTag(tag string) bool {
+	return slices.Contains(h.TagList(), NormalizeTag(tag))
+}
+
+// ParseTags parses a comma separated list of tags, e.g. "Health, work".
+// Tags are normalized and duplicates dropped.
+func ParseTags(s string) []string {
+	var tags []string
+	for _, part := range strings.Split(s, ",") {
+		if tag := NormalizeTag(part); tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// FormatTags formats tags as stored on the habit, e.g. "health,work"
+func FormatTags(tags []string) string {
+	return strings.Join(ParseTags(strings.Join(tags, ",")), ",")
+}
+
+// TagLess orders tags alphabetically, with "" (untagged) last
+func TagLess(a, b string) bool {
+	if a == "" || b == "" {
+		return b == "" && a != ""
+	}
+	return a < b
+}
+
+// NormalizeTag lowercases the tag and joins its words with dashes, e.g.
+// "Deep Work" becomes "deep-work"
+func NormalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+}
+
+// TimeOfDayLayout is how reminder times and windows are stored, e.g. "13:00"
+const TimeOfDayLayout = "15:04"
+
+// ParseTimeOfDay parses a time of day such as "13:00", "1:00 PM" or
+// "1pm" and returns it formatted as TimeOfDayLayout
+func ParseTimeOfDay(s string) (string, error) {
+	value := strings.ToUpper(strings.Join(strings.Fields(s), ""))
+	for _, layout := range []string{TimeOfDayLayout, "3:04PM", "3PM"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format(TimeOfDayLayout), nil
+		}
+	}
+	return "", fmt.Errorf("invalid time of day %q, use e.g. 13:00 or 1:00 PM", s)
+}
+
+// HasWindow reports whether the habit should be done in a time window
+func (h Habit) HasWindow() bool {
+	return h.WindowStart != "" || h.WindowEnd != ""
+}
+
+// WindowLabel describes the time window, e.g. "before 09:00" or
+// "06:00-09:00", empty if there is none
+func (h Habit) WindowLabel() string {
+	switch {
+	case h.WindowStart == "" && h.WindowEnd == "":
+		return ""
+	case h.WindowStart == "":
+		return "before " + h.WindowEnd
+	case h.WindowEnd == "":
+		return "after " + h.WindowStart
+	}
+	return h.WindowStart + "-" + h.WindowEnd
+}
+
+// ParseWindow parses a time window such as "06:00-09:00", "before 9am" or
+// "after 10pm" into its start and end, see ParseTimeOfDay
+func ParseWindow(s string) (start, end string, err error) {
+	value := strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case value == "":
+		return "", "", nil
+	case strings.HasPrefix(value, "before "):
+		end, err = ParseTimeOfDay(strings.TrimPrefix(value, "before "))
+	case strings.HasPrefix(value, "after "):
+		start, err = ParseTimeOfDay(strings.TrimPrefix(value, "after "))
+	default:
+		from, until, ok := strings.Cut(value, "-")
+		if !ok {
+			return "", "", fmt.Errorf("invalid time window %q, use e.g. 06:00-09:00, \"before 9am\" or \"after 10pm\"", s)
+		}
+		if start, err = ParseTimeOfDay(from); err == nil {
+			end, err = ParseTimeOfDay(until)
+		}
+	}
+	return start, end, err
+}
+
+// InWindow reports whether t, on the clock of cal, falls in the habit's
+// time window. The window starts at WindowStart and ends right before
+// WindowEnd. Habits without a window are always in it.
+func (h Habit) InWindow(cal period.Calendar, t time.Time) bool {
+	at := t.In(cal.Location).Format(TimeOfDayLayout) // compares as a string, "HH:MM"
+	if h.WindowStart != "" && at < h.WindowStart {
+		return false
+	}
+	return h.WindowEnd == "" || at < h.WindowEnd
+}
+
+// ReminderOn returns when the habit reminds on the day containing t, zero
+// if it has no reminder.
+func (h Habit) ReminderOn(cal period.Calendar, t time.Time) time.Time {
+	at, err := time.Parse(TimeOfDayLayout, h.RemindAt)
+	if err != nil {
+		return time.Time{}
+	}
+	day := cal.StartOfDay(t)
+	return time.Date(day.Year(), day.Month(), day.Day(), at.Hour(), at.Minute(), 0, 0, cal.Location)
+}
+
+// IsArchived reports whether the habit has been archived
+func (h Habit) IsArchived() bool {
+	return h.ArchivedAt != nil
+}
+
+// PauseCovering returns the pause the whole span falls into, nil if the
+// habit is not paused over all of it.
+func (h Habit) PauseCovering(cal period.Calendar, span period.Period) *HabitPause {
+	for i := range h.Pauses {
+		if h.Pauses[i].Covers(cal, span) {
+			return &h.Pauses[i]
+		}
+	}
+	return nil
+}
+
+// HabitPause suspends a habit over a range of days, e.g. a vacation.
+// Paused periods are not due and don't break streaks.
+type HabitPause struct {
+	gorm.Model
+	HabitID   uint       `gorm:"not null;index" json:"habit_id"`
+	StartedAt time.Time  `gorm:"not null" json:"started_at"` // first paused day
+	EndedAt   *time.Time `json:"ended_at"`                   // last paused day, nil until resumed
+	Reason    string     `gorm:"type:varchar(255)" json:"reason"`
+}
+
+// Covers reports whether every day of the span is paused
+func (p HabitPause) Covers(cal period.Calendar, span period.Period) bool {
+	if cal.StartOfDay(p.StartedAt).After(span.Start) {
+		return false
+	}
+	return p.EndedAt == nil || !cal.StartOfDay(*p.EndedAt).Before(span.End)
+}
+
+// Label describes the pause, e.g. "paused until 2026-10-20 (vacation)"
+func (p HabitPause) Label() string {
+	label := "paused"
+	if p.EndedAt != nil {
+		label += " until " + p.EndedAt.Format("2006-01-02")
+	}
+	if p.Reason != "" {
+		label += " (" + p.Reason + ")"
+	}
+	return label
+}
+
+// IsAvoid reports whether the habit is one to avoid. Logs of avoid habits
+// count relapses, a period is successful when none were logged. With a
+// time window, e.g. "no screens after 10pm", only relapses in it count.
+func (h Habit) IsAvoid() bool {
+	return h.Kind == KindAvoid
+}
+
+// IsMeasurable reports whether the habit tracks an amount in a unit rather
+// than a number of check-ins. TargetCount is the target amount then.
+func (h Habit) IsMeasurable() bool {
+	return h.Unit != ""
+}
+
+// FormatAmount formats an amount of the habit with its unit, e.g. "250 ml"
+func (h Habit) FormatAmount(amount int) string {
+	return FormatAmount(amount, h.Unit)
+}
+
+// FormatAmount formats an amount with an optional unit, e.g. "250 ml" or "3"
+func FormatAmount(amount int, unit string) string {
+	if unit == "" {
+		return fmt.Sprintf("%d", amount)
+	}
+	return fmt.Sprintf("%d %s", amount, unit)
 }
 
 // Defaults for Habit
-// TargetCount: 1
+// Kind: build
+// TargetCount: 1 (habits to avoid have none)
 // Interval: Daily
 // Description: "Default habit description"
 func (h *Habit) SetDefaults() {
-	if h.TargetCount <= 0 {
+	if h.Kind == "" {
+		h.Kind = KindBuild
+	}
+	if h.TargetCount <= 0 && !h.IsAvoid() {
 		h.TargetCount = 1
 	}
 	if h.Interval == "" {
@@ -49,23 +353,216 @@ func (h *Habit) ValidateHabit() error {
 	if h.Title == "" {
 		return fmt.Errorf("habit title cannot be empty")
 	}
-	if h.TargetCount <= 0 {
-		return fmt.Errorf("target count must be greater than 0")
+	switch h.Kind {
+	case "", KindBuild:
+		if h.TargetCount <= 0 {
+			return fmt.Errorf("target count must be greater than 0")
+		}
+	case KindAvoid:
+		if h.TargetCount != 0 || h.Unit != "" {
+			return fmt.Errorf("habits to avoid have no target count or unit")
+		}
+	default:
+		return fmt.Errorf("invalid habit kind: %s", h.Kind)
+	}
+	if len(h.Unit) > 50 {
+		return fmt.Errorf("unit cannot be longer than 50 characters")
+	}
+	if len(h.Tags) > 255 {
+		return fmt.Errorf("tags cannot be longer than 255 characters")
+	}
+	if h.RemindAt != "" {
+		if _, err := time.Parse(TimeOfDayLayout, h.RemindAt); err != nil {
+			return fmt.Errorf("invalid reminder time %q, expected HH:MM", h.RemindAt)
+		}
+		if h.IsAvoid() {
+			return fmt.Errorf("habits to avoid cannot have a reminder")
+		}
+	}
+	for _, at := range []string{h.WindowStart, h.WindowEnd} {
+		if _, err := time.Parse(TimeOfDayLayout, at); at != "" && err != nil {
+			return fmt.Errorf("invalid time window %q, expected HH:MM", at)
+		}
+	}
+	if h.HasWindow() {
+		if h.WindowStart != "" && h.WindowEnd != "" && h.WindowStart >= h.WindowEnd {
+			return fmt.Errorf("time window must start before it ends")
+		}
 	}
 	if !IsValidIntervalType(string(h.Interval)) {
 		return fmt.Errorf("invalid interval type: %s", h.Interval)
 	}
+	switch h.Interval {
+	case EveryNDays:
+		if h.EveryN <= 0 {
+			return fmt.Errorf("every_n_days habits need a period of at least 1 day")
+		}
+	case OnWeekdays:
+		days, err := ParseWeekdays(h.Weekdays)
+		if err != nil {
+			return err
+		}
+		if len(days) == 0 {
+			return fmt.Errorf("weekdays habits need at least one weekday")
+		}
+	}
 	return nil
 }
 
+// ScheduledWeekdays returns the weekdays a weekdays habit is due on.
+// Invalid entries are ignored, ValidateHabit reports them.
+func (h Habit) ScheduledWeekdays() []time.Weekday {
+	days, _ := ParseWeekdays(h.Weekdays)
+	return days
+}
+
+// ScheduleLabel returns a human readable description of the habit interval,
+// e.g. "daily", "every 3 days" or "mon, wed, fri".
+func (h Habit) ScheduleLabel() string {
+	switch h.Interval {
+	case EveryNDays:
+		if h.EveryN == 1 {
+			return "every day"
+		}
+		return fmt.Sprintf("every %d days", h.EveryN)
+	case OnWeekdays:
+		return strings.ReplaceAll(FormatWeekdays(h.ScheduledWeekdays()), ",", ", ")
+	}
+	return string(h.Interval)
+}
+
+// ParseWeekdays parses a comma separated list of weekdays ("mon,wed,fri").
+// Full names ("monday") are accepted as well, matching is case insensitive.
+func ParseWeekdays(s string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	seen := map[time.Weekday]bool{}
+	for _, part := range strings.Split(s, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		day, err := period.ParseWeekday(part)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+	return days, nil
+}
+
+// FormatWeekdays formats weekdays in calendar order as "mon,wed,fri".
+func FormatWeekdays(days []time.Weekday) string {
+	var names []string
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if slices.Contains(days, d) {
+			names = append(names, strings.ToLower(d.String()[:3]))
+		}
+	}
+	return strings.Join(names, ",")
+}
+
 type HabitLog struct {
 	gorm.Model
 	HabitID     uint         `gorm:"not null" json:"habit_id"`
 	Title       string       `gorm:"not null" json:"title"`
 	Interval    IntervalType `gorm:"type:varchar(100);not null" json:"interval"`
 	TargetCount int          `gorm:"not null" json:"target_count"`
-	ActualCount int          `gorm:"not null" json:"actual_count"`
-	EndedAt     time.Time    `gorm:"not null" json:"ended_at"`
+	ActualCount int          `gorm:"not null" json:"actual_count"` // summed amount for measurable habits
+	LateCount   int          `json:"late_count"`                   // part of ActualCount logged outside the time window
+	Unit        string       `gorm:"type:varchar(50)" json:"unit"`
+	StartedAt   time.Time    `json:"started_at"`               // first day of the period
+	EndedAt     time.Time    `gorm:"not null" json:"ended_at"` // last day of the period
+	// Skipped periods are excused, e.g. when sick. They are neither done nor
+	// missed, so they don't count towards completion rates or break streaks.
+	Skipped    bool   `json:"skipped"`
+	SkipReason string `gorm:"type:varchar(255)" json:"skip_reason"`
+}
+
+// CompletedOnTime reports whether the target was reached without the
+// amount logged late
+func (l HabitLog) CompletedOnTime() bool {
+	return l.ActualCount-l.LateCount >= l.TargetCount
+}
+
+// Relapses is the number of relapses of a habit to avoid that count, those
+// logged outside its time window are ignored
+func (l HabitLog) Relapses() int {
+	return l.ActualCount - l.LateCount
+}
+
+// SkipLabel describes a skipped period, e.g. "skipped (sick)"
+func (l HabitLog) SkipLabel() string {
+	if l.SkipReason == "" {
+		return "skipped"
+	}
+	return "skipped (" + l.SkipReason + ")"
+}
+
+// HabitEvent is a single log action on a habit, CreatedAt is when it
+// happened. A HabitLog's ActualCount is the sum of its events.
+type HabitEvent struct {
+	gorm.Model
+	HabitID    uint     `gorm:"not null;index" json:"habit_id"`
+	HabitLogID uint     `gorm:"not null;index" json:"habit_log_id"`
+	HabitLog   HabitLog `json:"-"`
+	Amount     int      `gorm:"not null" json:"amount"`
+	Note       string   `gorm:"type:text" json:"note"`
+	Late       bool     `json:"late"` // logged outside the habit's time window
+}
+
+type HabitEventView struct {
+	ID       uint   `json:"id"`
+	LoggedAt string `json:"logged_at"`
+	Period   string `json:"period"` // last day of the period the event counts towards
+	Amount   string `json:"amount"`
+	Note     string `json:"note"`
+	Late     bool   `json:"late"`
+}
+
+// HabitNoteView is a log event with a note, e.g. a search result
+type HabitNoteView struct {
+	ID       uint   `json:"id"`
+	HabitID  uint   `json:"habit_id"`
+	Title    string `json:"title"`
+	LoggedAt string `json:"logged_at"`
+	Period   string `json:"period"` // last day of the period the event counts towards
+	Amount   string `json:"amount"`
+	Note     string `json:"note"`
+}
+
+// ToHabitNoteViews needs the events' HabitLog loaded, titles and units are
+// taken from there
+func ToHabitNoteViews(events []HabitEvent) []HabitNoteView {
+	views := make([]HabitNoteView, len(events))
+	for i, e := range events {
+		views[i] = HabitNoteView{
+			ID:       e.ID,
+			HabitID:  e.HabitID,
+			Title:    e.HabitLog.Title,
+			LoggedAt: e.CreatedAt.Format("2006-01-02 15:04"),
+			Period:   e.HabitLog.EndedAt.Format("2006-01-02"),
+			Amount:   FormatAmount(e.Amount, e.HabitLog.Unit),
+			Note:     e.Note,
+		}
+	}
+	return views
+}
+
+func ToHabitEventViews(h Habit, events []HabitEvent) []HabitEventView {
+	views := make([]HabitEventView, len(events))
+	for i, e := range events {
+		views[i] = HabitEventView{
+			ID:       e.ID,
+			LoggedAt: e.CreatedAt.Format("2006-01-02 15:04"),
+			Period:   e.HabitLog.EndedAt.Format("2006-01-02"),
+			Amount:   h.FormatAmount(e.Amount),
+			Note:     e.Note,
+			Late:     e.Late,
+		}
+	}
+	return views
 }
 
 type HabitLogView struct {
@@ -74,44 +571,239 @@ type HabitLogView struct {
 	Title       string       `json:"title"`
 	TargetCount int          `json:"target_count"`
 	ActualCount int          `json:"actual_count"`
+	Unit        string       `json:"unit"`
 	Interval    IntervalType `json:"interval"`
 	StartedAt   string       `json:"started_at"`
 	EndedAt     string       `json:"ended_at"`
+	Skipped     string       `json:"skipped"` // e.g. "skipped (sick)", "-" if not skipped
+	Notes       string       `json:"notes"`   // notes of the log's events, "-" if none
 }
 
 func ToHabitLogViews(habitLogs []HabitLog) []HabitLogView {
 	habitViews := make([]HabitLogView, len(habitLogs))
 	for i, log := range habitLogs {
+		startedAt := log.StartedAt
+		if startedAt.IsZero() { // logs created before periods were stored
+			startedAt = log.CreatedAt
+		}
+		skipped := "-"
+		if log.Skipped {
+			skipped = log.SkipLabel()
+		}
 		habitViews[i] = HabitLogView{
 			ID:          log.ID,
 			HabitID:     log.HabitID,
 			ActualCount: log.ActualCount,
 			TargetCount: log.TargetCount,
-			StartedAt:   log.CreatedAt.Format("2006-01-02"),
+			Unit:        log.Unit,
+			StartedAt:   startedAt.Format("2006-01-02"),
 			EndedAt:     log.EndedAt.Format("2006-01-02"),
 			Interval:    log.Interval,
 			Title:       log.Title,
+			Skipped:     skipped,
+			Notes:       "-",
 		}
 	}
 	return habitViews
 }
 
+// ToHabitLogViewsWithNotes is ToHabitLogViews with the notes of each log,
+// by log ID, filled in
+func ToHabitLogViewsWithNotes(habitLogs []HabitLog, notes map[uint][]string) []HabitLogView {
+	views := ToHabitLogViews(habitLogs)
+	for i, log := range habitLogs {
+		if n := notes[log.ID]; len(n) > 0 {
+			views[i].Notes = strings.Join(n, "; ")
+		}
+	}
+	return views
+}
+
 type HabitView struct {
 	ID          uint         `json:"id"`
 	Title       string       `json:"title"`
 	Description string       `json:"description"`
+	Kind        HabitKind    `json:"kind"`
 	Interval    IntervalType `json:"interval"`
+	Schedule    string       `json:"schedule"`
 	TargetCount int          `json:"target_count"`
+	Unit        string       `json:"unit"`
+	Tags        string       `json:"tags"`
+	RemindAt    string       `json:"remind_at"`
+	Window      string       `json:"window"`    // e.g. "before 09:00"
+	Status      string       `json:"status"`    // active, archived or e.g. "paused until 2026-10-20"
+	Streak      string       `json:"streak"`    // e.g. "3 (best 7)", "-" if unknown
+	LastDone    string       `json:"last_done"` // start of the last completed period, days clean for habits to avoid
+	Pinned      bool         `json:"pinned"`
 }
 
 func ToHabitView(h Habit) HabitView {
+	status := "active"
+	if h.IsArchived() {
+		status = "archived"
+	}
 	return HabitView{
 		ID:          h.ID,
 		Title:       h.Title,
 		Description: h.Description,
+		Kind:        h.Kind,
 		Interval:    h.Interval,
+		Schedule:    h.ScheduleLabel(),
 		TargetCount: h.TargetCount,
+		Unit:        h.Unit,
+		Tags:        strings.Join(h.TagList(), ", "),
+		RemindAt:    h.RemindAt,
+		Window:      h.WindowLabel(),
+		Status:      status,
+		Streak:      "-",
+		LastDone:    "-",
+		Pinned:      h.Pinned,
+	}
+}
+
+// ToHabitViewWithStreak is ToHabitView with the streak columns filled in
+func ToHabitViewWithStreak(h Habit, streak HabitStreak) HabitView {
+	hv := ToHabitView(h)
+	hv.Streak = fmt.Sprintf("%d (best %d)", streak.Current, streak.Longest)
+	if h.IsAvoid() {
+		hv.LastDone = fmt.Sprintf("%d days clean", streak.DaysSinceRelapse)
+	} else if !streak.LastCompleted.IsZero() {
+		hv.LastDone = streak.LastCompleted.Format("2006-01-02")
+	}
+	return hv
+}
+
+// HabitAnalytics sums up the whole history of a habit. Only settled
+// periods count: past ones, and the current one once completed (or, for
+// habits to avoid, once relapsed).
+type HabitAnalytics struct {
+	Habit           Habit
+	Streak          HabitStreak
+	PeriodsDue      int
+	Completed       int // clean periods for habits to avoid
+	CompletedOnTime int // completed periods with the target reached in the time window
+	CompletionRate  float64
+	CheckIns        int            // summed amount of all logs, relapses for habits to avoid
+	Months          []MonthStats   // oldest first
+	Weekdays        []WeekdayStats // starting with the first day of the week
+	ByCheckIns      bool           // periods are longer than a day, weekdays are compared by check-ins
+	BestWeekday     *WeekdayStats  // nil without enough history
+	WorstWeekday    *WeekdayStats
+	Recent          []HabitEventView // most recent first
+}
+
+// MonthStats is the completion of the periods ending in a month
+type MonthStats struct {
+	Month          time.Time // first day of the month
+	PeriodsDue     int
+	Completed      int
+	CompletionRate float64
+}
+
+type MonthStatsView struct {
+	Month     string `json:"month"` // e.g. "Oct 2026"
+	Due       int    `json:"due"`
+	Completed int    `json:"completed"`
+	Rate      string `json:"rate"` // e.g. "80%"
+}
+
+func ToMonthStatsViews(months []MonthStats) []MonthStatsView {
+	views := make([]MonthStatsView, len(months))
+	for i, ms := range months {
+		views[i] = MonthStatsView{
+			Month:     ms.Month.Format("Jan 2006"),
+			Due:       ms.PeriodsDue,
+			Completed: ms.Completed,
+			Rate:      fmt.Sprintf("%.0f%%", ms.CompletionRate),
+		}
 	}
+	return views
+}
+
+// WeekdayStats is how a habit does on one day of the week. Due and Done
+// count the days of habits tracked per day, CheckIns the check-ins (or
+// relapses) logged on that weekday.
+type WeekdayStats struct {
+	Weekday  time.Weekday
+	Due      int
+	Done     int
+	CheckIns int
+}
+
+// Rate is the share of due days done in percent
+func (ws WeekdayStats) Rate() float64 {
+	if ws.Due == 0 {
+		return 0
+	}
+	return float64(ws.Done) * 100 / float64(ws.Due)
+}
+
+// HeatmapDay is a single day of a habit heatmap
+type HeatmapDay struct {
+	Date  time.Time // midnight of the day
+	Due   bool      // false on rest days, while paused and outside the habit's lifetime
+	Ratio float64   // share of the target reached in the day's period, 0 to 1
+	// Skipped days are not due either, their period was excused
+	Skipped bool
+}
+
+// HeatmapLevels is the number of shades a due day is drawn in
+const HeatmapLevels = 5
+
+// Level buckets Ratio into 0 (nothing done) to HeatmapLevels-1 (target
+// reached). Days that were not due are -1.
+func (d HeatmapDay) Level() int {
+	switch {
+	case !d.Due:
+		return -1
+	case d.Ratio >= 1:
+		return HeatmapLevels - 1
+	case d.Ratio <= 0:
+		return 0
+	}
+	return 1 + int(d.Ratio*float64(HeatmapLevels-2))
+}
+
+// Heatmap is a calendar of days, one column per week
+type Heatmap struct {
+	Title string
+	Days  []HeatmapDay // consecutive, the first day starts a week
+}
+
+// Weeks splits the days into weeks. The last week may be shorter.
+func (hm Heatmap) Weeks() [][]HeatmapDay {
+	var weeks [][]HeatmapDay
+	for i := 0; i < len(hm.Days); i += 7 {
+		weeks = append(weeks, hm.Days[i:min(i+7, len(hm.Days))])
+	}
+	return weeks
+}
+
+// MonthStarts returns the indexes of the weeks a month starts in, where a
+// month label goes. The first month is left out when its label would run
+// into the next one.
+func (hm Heatmap) MonthStarts() []int {
+	weeks := hm.Weeks()
+	var starts []int
+	for i, week := range weeks {
+		if i == 0 || week[0].Date.Month() != weeks[i-1][0].Date.Month() {
+			starts = append(starts, i)
+		}
+	}
+	if len(starts) > 1 && starts[1] < 4 {
+		starts = starts[1:]
+	}
+	return starts
+}
+
+// HabitStreak counts consecutive completed periods of a habit
+type HabitStreak struct {
+	Current       int       `json:"current"`
+	Longest       int       `json:"longest"`
+	LastCompleted time.Time `json:"last_completed"` // start of the last completed period, zero if never
+	// Habits to avoid only
+	LastRelapse      time.Time `json:"last_relapse"`       // day of the most recent relapse, zero if never
+	DaysSinceRelapse int       `json:"days_since_relapse"` // since the habit was created if it never relapsed
 }
 
 func IsValidIntervalType(interval string) bool {
@@ -125,19 +817,36 @@ func IsValidIntervalType(interval string) bool {
 
 type Intent struct {
 	gorm.Model
-	Name    string     `gorm:"not null" json:"name"`
-	Status  string     `gorm:"default:active" json:"status"`
-	EndedAt *time.Time `json:"ended_at,omitempty"`
+	Name          string         `gorm:"not null" json:"name"`
+	Status        string         `gorm:"default:active" json:"status"`
+	EndedAt       *time.Time     `json:"ended_at,omitempty"`
+	FocusSessions []FocusSession `json:"focus_sessions,omitempty"`
+}
+
+// FocusMinutes is the time focused on the intent up to now, across its
+// sessions. Stale sessions count up to the idle cutoff, see FocusedMinutes.
+func (i Intent) FocusMinutes(now time.Time, cutoff time.Duration) float64 {
+	total := 0.0
+	for _, session := range i.FocusSessions {
+		total += session.FocusedMinutes(now, cutoff)
+	}
+	return total
+}
+
+// FocusTime is FocusMinutes formatted, e.g. "1hr 20min"
+func (i Intent) FocusTime(now time.Time, cutoff time.Duration) string {
+	return utils.FormatMinutes(i.FocusMinutes(now, cutoff))
 }
 
 type IntentView struct {
-	ID      uint
-	Name    string
-	Status  string
-	EndedAt string
+	ID        uint
+	Name      string
+	Status    string
+	EndedAt   string
+	FocusTime string // e.g. "1hr 20min"
 }
 
-func ToIntentView(i Intent) IntentView {
+func ToIntentView(i Intent, idleCutoff time.Duration) IntentView {
 	var ended string
 	if i.EndedAt != nil {
 		ended = i.EndedAt.Format("2006-01-02 15:04")
@@ -145,20 +854,132 @@ func ToIntentView(i Intent) IntentView {
 		ended = "-"
 	}
 	return IntentView{
-		ID:      i.ID,
-		Name:    i.Name,
-		Status:  i.Status,
-		EndedAt: ended,
+		ID:        i.ID,
+		Name:      i.Name,
+		Status:    i.Status,
+		EndedAt:   ended,
+		FocusTime: i.FocusTime(time.Now(), idleCutoff),
 	}
 }
 
 type FocusSession struct {
 	gorm.Model
-	Title    string    `gorm:"not null" json:"title"`        // e.g., "Work on project"
-	Status   string    `gorm:"default:active" json:"status"` // active, paused
-	EndTime  time.Time `json:"end_time"`
-	Duration float64   `json:"duration"`                 // in mins
-	Rating   int       `gorm:"default:-1" json:"rating"` // 0 to 10, optional
+	Title    string         `gorm:"not null" json:"title"`        // e.g., "Work on project"
+	Status   string         `gorm:"default:active" json:"status"` // active, paused or ended
+	EndTime  time.Time      `json:"end_time"`
+	Duration float64        `json:"duration"`                         // in mins, time worked without the pauses
+	Rating   int            `gorm:"default:-1" json:"rating"`         // 0 to 10, optional
+	Pomodoro string         `gorm:"type:varchar(20)" json:"pomodoro"` // plan of pomodoro sessions, e.g. "25/5x4"
+	IntentID *uint          `gorm:"index" json:"intent_id"`           // intent the session works towards, if any
+	Intent   *Intent        `json:"intent,omitempty"`
+	Segments []FocusSegment `json:"segments,omitempty"` // ordered by StartedAt
+	// PlannedMinutes is how long the session was meant to run, 0 if open
+	// ended. Planned sessions are ended once they reach it, see AutoEnded.
+	PlannedMinutes int  `json:"planned_minutes"`
+	AutoEnded      bool `json:"auto_ended"` // ended at its planned time rather than by hand
+}
+
+// IsPlanned reports whether the session was started with a planned duration
+func (fs FocusSession) IsPlanned() bool {
+	return fs.PlannedMinutes > 0
+}
+
+// PlanDelta is how much longer than planned the session ran, negative if it
+// ended early. It is 0 for sessions without a plan.
+func (fs FocusSession) PlanDelta(now time.Time) float64 {
+	if !fs.IsPlanned() {
+		return 0
+	}
+	return fs.ActiveMinutes(now) - float64(fs.PlannedMinutes)
+}
+
+// Plan describes the planned duration of the session and, once ended, how
+// it went against it, e.g. "50min, 10min over". "-" if it has none.
+func (fs FocusSession) Plan(now time.Time) string {
+	if !fs.IsPlanned() {
+		return "-"
+	}
+	plan := utils.FormatMinutes(float64(fs.PlannedMinutes))
+	if fs.Status != "ended" {
+		return plan
+	}
+	if fs.AutoEnded {
+		return plan + ", auto-ended"
+	}
+	switch delta := math.Round(fs.PlanDelta(now)); {
+	case delta > 0:
+		return fmt.Sprintf("%s, %s over", plan, utils.FormatMinutes(delta))
+	case delta < 0:
+		return fmt.Sprintf("%s, %s under", plan, utils.FormatMinutes(-delta))
+	}
+	return plan + ", on time"
+}
+
+// ActiveMinutes is the time worked in the session up to now, pauses and
+// pomodoro breaks left out. Sessions started before segments were recorded
+// count from CreatedAt.
+func (fs FocusSession) ActiveMinutes(now time.Time) float64 {
+	if len(fs.Segments) == 0 {
+		if fs.Status == "ended" {
+			return fs.Duration
+		}
+		return now.Sub(fs.CreatedAt).Minutes()
+	}
+	total := 0.0
+	for _, seg := range fs.Segments {
+		if !seg.Break {
+			total += seg.Minutes(now)
+		}
+	}
+	return total
+}
+
+// IsStale reports whether the session is still open and has run for longer
+// than the idle cutoff, most likely because it was never ended. Paused
+// sessions don't run, so they only go stale if they did before the pause.
+func (fs FocusSession) IsStale(now time.Time, cutoff time.Duration) bool {
+	return fs.Status != "ended" && cutoff > 0 && fs.ActiveMinutes(now) > cutoff.Minutes()
+}
+
+// FocusedMinutes is ActiveMinutes as counted in stats: stale sessions count
+// up to the idle cutoff only, until their real end is set.
+func (fs FocusSession) FocusedMinutes(now time.Time, cutoff time.Duration) float64 {
+	if fs.IsStale(now, cutoff) {
+		return cutoff.Minutes()
+	}
+	return fs.ActiveMinutes(now)
+}
+
+// Pomodoros counts the work intervals of the session that ran their full length
+func (fs FocusSession) Pomodoros() int {
+	n := 0
+	for _, seg := range fs.Segments {
+		if seg.Pomodoro {
+			n++
+		}
+	}
+	return n
+}
+
+// FocusSegment is a stretch of work in a focus session, from its start or
+// resume to the next pause or its end. Pomodoro sessions record their
+// breaks as segments too.
+type FocusSegment struct {
+	gorm.Model
+	FocusSessionID uint       `gorm:"not null;index" json:"focus_session_id"`
+	StartedAt      time.Time  `gorm:"not null" json:"started_at"`
+	EndedAt        *time.Time `json:"ended_at"` // nil while the segment runs
+	Break          bool       `json:"break"`    // a pomodoro break, not focus time
+	Pomodoro       bool       `json:"pomodoro"` // a pomodoro work interval that ran its full length
+}
+
+// Minutes is the length of the segment, up to now while it runs
+func (fs FocusSegment) Minutes(now time.Time) float64 {
+	end := now
+	if fs.EndedAt != nil {
+		end = *fs.EndedAt
+	}
+	return end.Sub(fs.StartedAt).Minutes()
 }
 
 type FocusSessionView struct {
@@ -169,9 +990,11 @@ type FocusSessionView struct {
 	Duration  float64 `json:"duration"`   // in mins
 	Rating    int     `json:"rating"`     // 0 to 10, -1 if not rated
 	CreatedAt string  `json:"created_at"` // formatted as "2006-01-02 15:04:05"
+	Intent    string  `json:"intent"`     // name of the intent, "-" if none
+	Plan      string  `json:"plan"`       // planned duration and over or underrun, "-" if none
 }
 
-func ToFocusSessionView(fs FocusSession) FocusSessionView {
+func ToFocusSessionView(fs FocusSession, idleCutoff time.Duration) FocusSessionView {
 	fsv := FocusSessionView{
 		ID:        fs.ID,
 		Title:     fs.Title,
@@ -188,9 +1011,15 @@ func ToFocusSessionView(fs FocusSession) FocusSessionView {
 	if fs.Rating == 0 {
 		fsv.Rating = -1 // indicate no rating given
 	}
-	now := time.Now()
-	fsv.Duration = now.Sub(fs.CreatedAt).Minutes()
-	fsv.Duration = math.Floor(fsv.Duration) // todo: fix decimals
+	fsv.Intent = "-"
+	if fs.Intent != nil {
+		fsv.Intent = fs.Intent.Name
+	}
+	fsv.Duration = math.Floor(fs.ActiveMinutes(time.Now())) // todo: fix decimals
+	fsv.Plan = fs.Plan(time.Now())
+	if fs.IsStale(time.Now(), idleCutoff) {
+		fsv.Status += " (stale)"
+	}
 	return fsv
 }
 
@@ -217,6 +1046,86 @@ func ToJournalEntryView(entry JournalEntry) JournalEntryView {
 	}
 }
 
+// Migration marks a one-time data migration as applied
+type Migration struct {
+	Name      string    `gorm:"primaryKey;type:varchar(100)" json:"name"`
+	AppliedAt time.Time `gorm:"not null" json:"applied_at"`
+}
+
+// Routine is a named, ordered list of habits and focus blocks done in one
+// go, e.g. a morning routine
+type Routine struct {
+	gorm.Model
+	Name        string        `gorm:"type:varchar(100)" json:"name"`
+	Description string        `gorm:"type:text" json:"description"`
+	Steps       []RoutineStep `json:"steps,omitempty"` // ordered by Position
+}
+
+// RoutineStep is a habit to log or, without a habit, a focus block
+type RoutineStep struct {
+	gorm.Model
+	RoutineID    uint   `gorm:"not null;index" json:"routine_id"`
+	Position     int    `gorm:"not null" json:"position"` // 1 based
+	HabitID      *uint  `json:"habit_id"`
+	Habit        *Habit `json:"habit,omitempty"`
+	FocusTitle   string `gorm:"type:varchar(100)" json:"focus_title"`
+	FocusMinutes int    `json:"focus_minutes"` // planned length of the focus block
+}
+
+// IsFocus reports whether the step is a focus block rather than a habit
+func (rs RoutineStep) IsFocus() bool {
+	return rs.HabitID == nil
+}
+
+// Label describes the step, e.g. "Meditate" or "focus: Deep work (25m)"
+func (rs RoutineStep) Label() string {
+	if !rs.IsFocus() {
+		if rs.Habit == nil || rs.Habit.ID == 0 {
+			return "(deleted habit)"
+		}
+		return rs.Habit.Title
+	}
+	if rs.FocusMinutes > 0 {
+		return fmt.Sprintf("focus: %s (%dm)", rs.FocusTitle, rs.FocusMinutes)
+	}
+	return "focus: " + rs.FocusTitle
+}
+
+// RoutineRun is a single walk through a routine
+type RoutineRun struct {
+	gorm.Model
+	RoutineID  uint      `gorm:"not null;index" json:"routine_id"`
+	Name       string    `gorm:"type:varchar(100)" json:"name"` // of the routine when it was run
+	StepsTotal int       `json:"steps_total"`                   // steps due, rest days and paused habits are left out
+	StepsDone  int       `json:"steps_done"`
+	EndedAt    time.Time `json:"ended_at"`
+}
+
+// Completed reports whether every step due was done
+func (rr RoutineRun) Completed() bool {
+	return rr.StepsDone >= rr.StepsTotal
+}
+
+type RoutineView struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Steps       string `json:"steps"` // e.g. "Meditate → Stretch → focus: Plan (15m)"
+}
+
+func ToRoutineView(r Routine) RoutineView {
+	steps := make([]string, len(r.Steps))
+	for i, step := range r.Steps {
+		steps[i] = step.Label()
+	}
+	return RoutineView{
+		ID:          r.ID,
+		Name:        r.Name,
+		Description: r.Description,
+		Steps:       strings.Join(steps, " → "),
+	}
+}
+
 func IsValidMode(mode string) bool {
 	for _, item := range config.AllModes {
 		if item == mode {
@@ -230,23 +1139,73 @@ type FocusStats struct {
 	TotalSessions  int
 	TotalDuration  string
 	LongestSession string
+	Pomodoros      int // completed pomodoro work intervals
+	// Ended sessions that had a planned duration, and by how much they ran
+	// over or under it in total, e.g. "25min"
+	PlannedSessions int
+	Overrun         string
+	Underrun        string
+	StaleSessions   int // open past the idle cutoff, counted up to it only
 }
 
 type HabitStats struct {
 	HabitName      string
+	Tags           []string
 	CompletionRate float64
 	LogsTracked    int
 	LogsCompleted  int
+	Archived       bool
+	Avoid          bool // LogsCompleted counts clean periods, TotalAmount relapses
+	PeriodsDue     int  // scheduled periods in the range, the completion rate denominator
+	Skipped        int  // skipped periods in the range, not part of PeriodsDue
+	Unit           string
+	TotalAmount    int // summed amount (check-ins for plain habits) logged in the range
+	CurrentStreak  int
+	LongestStreak  int
+	LastCompleted  string // "-" if never completed
+	// Habits to avoid only
+	DaysSinceRelapse int
+	// Habits with a time window only, completions split by whether the
+	// target was reached in the window. Rates share the completion rate's
+	// denominator.
+	Window          string // e.g. "before 09:00"
+	CompletedOnTime int
+	CompletedLate   int
+	OnTimeRate      float64
+	LateRate        float64
+}
+
+// TagStats sums up the habits sharing a tag. Habits with several tags
+// count towards each of them.
+type TagStats struct {
+	Tag            string // "" for untagged habits
+	Habits         int
+	PeriodsDue     int
+	LogsCompleted  int
+	CompletionRate float64
 }
 
 type IntentStats struct {
 	IntentName string
 	Status     string
+	FocusTime  string // focused on the intent in total, e.g. "1hr 20min"
+}
+
+// RoutineStats sums up the runs of a routine
+type RoutineStats struct {
+	Name           string
+	Runs           int
+	Completed      int // runs with every step due done
+	CompletionRate float64
+	StepsDone      int
+	StepsTotal     int
 }
 
 type SummaryReport struct {
 	DateRange string
 	Focus     FocusStats
 	Habits    []HabitStats
+	Tags      []TagStats // only when some habit is tagged
+	Routines  []RoutineStats
 	Intents   []IntentStats
 }